@@ -0,0 +1,208 @@
+package web
+
+import (
+	"expvar"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"net/netip"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// AdminOption 管理引擎的可选项
+type AdminOption func(a *admin)
+
+// admin 管理引擎的配置
+type admin struct {
+	app       *Engine        // 被诊断的业务引擎
+	auth      []HandleFunc   // 鉴权中间件
+	logLevel  *slog.LevelVar // 可以在运行时修改的日志级别
+	startTime time.Time      // 管理引擎的创建时间
+	opts      []EngineOption // 管理引擎自身的选项
+}
+
+// WithAdminAuth 设置管理接口的鉴权中间件，默认只允许本机访问
+func WithAdminAuth(middlewares ...HandleFunc) AdminOption {
+	return func(a *admin) {
+		a.auth = middlewares
+	}
+}
+
+// WithAdminLogLevel 开启 /debug/loglevel，通过 GET 查询、PUT 修改 lv 的值，例如 PUT debug
+// lv 通常是 slog.HandlerOptions.Level
+func WithAdminLogLevel(lv *slog.LevelVar) AdminOption {
+	return func(a *admin) {
+		a.logLevel = lv
+	}
+}
+
+// WithAdminEngineOptions 设置管理引擎自身的选项
+func WithAdminEngineOptions(opts ...EngineOption) AdminOption {
+	return func(a *admin) {
+		a.opts = opts
+	}
+}
+
+// NewAdminEngine 创建用于诊断 app 的管理引擎，应在单独的端口上启动，不要暴露到公网
+//   - /debug/pprof/ pprof 性能分析
+//   - /debug/vars expvar 变量
+//   - /debug/routes app 的路由表
+//   - /debug/build 构建信息
+//   - /debug/runtime goroutine 数量、app 的连接数和就绪状态等运行时信息
+//   - /debug/loglevel 日志级别，需要 WithAdminLogLevel
+func NewAdminEngine(app *Engine, opts ...AdminOption) *Engine {
+	a := &admin{
+		app:       app,
+		auth:      []HandleFunc{LocalOnly()},
+		startTime: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	res := NewEngine(a.opts...)
+	g := res.Group("/debug")
+	g.Use(a.auth...)
+
+	g.GET("/pprof", pprofIndex)
+	g.GET("/pprof/:name", pprofHandler)
+	g.POST("/pprof/:name", pprofHandler)
+	g.GET("/vars", WrapHandler(expvar.Handler()))
+	g.GET("/routes", func(ctx *Context) {
+		_ = ctx.JSON(http.StatusOK, a.app.Routes())
+	})
+	g.GET("/build", a.build)
+	g.GET("/runtime", a.runtime)
+	if a.logLevel != nil {
+		g.GET("/loglevel", a.getLogLevel)
+		g.PUT("/loglevel", a.setLogLevel)
+	}
+	return res
+}
+
+// LocalOnly 只允许本机访问的中间件，其他地址返回 403
+func LocalOnly() HandleFunc {
+	return func(ctx *Context) {
+		ip, err := netip.ParseAddr(ctx.ClientIP())
+		if err != nil || !ip.IsLoopback() {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// pprofIndex pprof 首页使用 goroutine?debug=1 这样的相对链接，
+// 没有以 / 结尾时重定向到 /debug/pprof/，否则链接会解析到 /debug 下
+func pprofIndex(ctx *Context) {
+	if !strings.HasSuffix(ctx.Req.URL.Path, "/") {
+		target := *ctx.Req.URL
+		target.Path += "/"
+		if err := ctx.Redirect(http.StatusMovedPermanently, target.RequestURI()); err != nil {
+			ctx.AbortWithError(err)
+		}
+		return
+	}
+	WrapHandler(http.HandlerFunc(pprof.Index))(ctx)
+}
+
+// pprofHandler 根据名字分发 pprof 请求
+func pprofHandler(ctx *Context) {
+	var h http.Handler
+	switch name := ctx.PathParams["name"]; name {
+	case "cmdline":
+		h = http.HandlerFunc(pprof.Cmdline)
+	case "profile":
+		h = http.HandlerFunc(pprof.Profile)
+	case "symbol":
+		h = http.HandlerFunc(pprof.Symbol)
+	case "trace":
+		h = http.HandlerFunc(pprof.Trace)
+	default:
+		h = pprof.Handler(name)
+	}
+	WrapHandler(h)(ctx)
+}
+
+// buildInfo 构建信息
+type buildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings"`
+	Deps      map[string]string `json:"deps"`
+}
+
+// build 返回构建信息
+func (a *admin) build(ctx *Context) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		_ = ctx.Problem(NewProblem(http.StatusNotFound, "build info not available"))
+		return
+	}
+	res := buildInfo{
+		GoVersion: info.GoVersion,
+		Path:      info.Main.Path,
+		Version:   info.Main.Version,
+		Settings:  make(map[string]string, len(info.Settings)),
+		Deps:      make(map[string]string, len(info.Deps)),
+	}
+	for _, s := range info.Settings {
+		res.Settings[s.Key] = s.Value
+	}
+	for _, dep := range info.Deps {
+		res.Deps[dep.Path] = dep.Version
+	}
+	_ = ctx.JSON(http.StatusOK, res)
+}
+
+// runtimeInfo 运行时信息
+type runtimeInfo struct {
+	Goroutines  int    `json:"goroutines"`
+	ActiveConns int64  `json:"active_conns"`
+	Ready       bool   `json:"ready"`
+	NumCPU      int    `json:"num_cpu"`
+	GOMAXPROCS  int    `json:"gomaxprocs"`
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapObjects uint64 `json:"heap_objects"`
+	NumGC       uint32 `json:"num_gc"`
+	Uptime      string `json:"uptime"`
+}
+
+// runtime 返回运行时信息
+func (a *admin) runtime(ctx *Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	_ = ctx.JSON(http.StatusOK, runtimeInfo{
+		Goroutines:  runtime.NumGoroutine(),
+		ActiveConns: a.app.ActiveConns(),
+		Ready:       a.app.Ready(),
+		NumCPU:      runtime.NumCPU(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapObjects: mem.HeapObjects,
+		NumGC:       mem.NumGC,
+		Uptime:      time.Since(a.startTime).Round(time.Second).String(),
+	})
+}
+
+// getLogLevel 返回当前的日志级别
+func (a *admin) getLogLevel(ctx *Context) {
+	_ = ctx.String(http.StatusOK, a.logLevel.Level().String())
+}
+
+// setLogLevel 修改日志级别，请求体为 debug、info、warn、error 或 info+2 等形式
+func (a *admin) setLogLevel(ctx *Context) {
+	body, err := ctx.Body()
+	if err != nil {
+		ctx.AbortWithError(err)
+		return
+	}
+	if err = a.logLevel.UnmarshalText([]byte(strings.TrimSpace(string(body)))); err != nil {
+		ctx.AbortWithError(&BindError{Err: err})
+		return
+	}
+	_ = ctx.String(http.StatusOK, a.logLevel.Level().String())
+}
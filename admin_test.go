@@ -0,0 +1,150 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Routes(t *testing.T) {
+	e := NewEngine()
+	e.GET("/user/:id", func(ctx *Context) {})
+	e.POST("/user", func(ctx *Context) {})
+	e.GET("/user", func(ctx *Context) {})
+	e.GET("/static/*", func(ctx *Context) {})
+	e.NameRoute("user", "/user/:id")
+
+	routes := e.Routes()
+	require.Len(t, routes, 4)
+	got := make([]string, 0, len(routes))
+	for _, r := range routes {
+		got = append(got, r.Method+" "+r.Path)
+		assert.Contains(t, r.Handler, "TestEngine_Routes")
+	}
+	assert.Equal(t, []string{"GET /static/*", "GET /user", "POST /user", "GET /user/:id"}, got)
+	assert.Equal(t, "user", routes[3].Name)
+}
+
+func TestNewAdminEngine(t *testing.T) {
+	app := NewEngine()
+	app.GET("/user/:id", func(ctx *Context) {})
+	level := new(slog.LevelVar)
+	admin := NewAdminEngine(app, WithAdminLogLevel(level))
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:12345"
+		recorder := httptest.NewRecorder()
+		admin.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "pprof index", method: http.MethodGet, path: "/debug/pprof/", wantCode: http.StatusOK, wantBody: "goroutine"},
+		{name: "pprof index without slash", method: http.MethodGet, path: "/debug/pprof", wantCode: http.StatusMovedPermanently},
+		{name: "pprof profile", method: http.MethodGet, path: "/debug/pprof/goroutine?debug=1", wantCode: http.StatusOK, wantBody: "goroutine profile"},
+		{name: "pprof cmdline", method: http.MethodGet, path: "/debug/pprof/cmdline", wantCode: http.StatusOK},
+		{name: "expvar", method: http.MethodGet, path: "/debug/vars", wantCode: http.StatusOK, wantBody: `"memstats"`},
+		{name: "routes", method: http.MethodGet, path: "/debug/routes", wantCode: http.StatusOK, wantBody: `"path":"/user/:id"`},
+		{name: "build", method: http.MethodGet, path: "/debug/build", wantCode: http.StatusOK, wantBody: `"go_version"`},
+		{name: "runtime", method: http.MethodGet, path: "/debug/runtime", wantCode: http.StatusOK, wantBody: `"goroutines"`},
+		{name: "get log level", method: http.MethodGet, path: "/debug/loglevel", wantCode: http.StatusOK, wantBody: "INFO"},
+		{name: "set log level", method: http.MethodPut, path: "/debug/loglevel", body: "debug\n", wantCode: http.StatusOK, wantBody: "DEBUG"},
+		{name: "invalid log level", method: http.MethodPut, path: "/debug/loglevel", body: "verbose", wantCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serve(tc.method, tc.path, tc.body)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tc.wantBody)
+		})
+	}
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	// pprof 首页的相对链接需要以 / 结尾的地址
+	recorder := serve(http.MethodGet, "/debug/pprof?debug=1", "")
+	assert.Equal(t, "/debug/pprof/?debug=1", recorder.Header().Get("Location"))
+
+	// 默认只允许本机访问
+	req := httptest.NewRequest(http.MethodGet, "/debug/routes", nil)
+	recorder = httptest.NewRecorder()
+	admin.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestNewAdminEngine_Auth(t *testing.T) {
+	admin := NewAdminEngine(NewEngine(), WithAdminAuth(func(ctx *Context) {
+		if ctx.GetHeader("Authorization") != "Bearer secret" {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.Next()
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/debug/runtime", nil)
+	recorder := httptest.NewRecorder()
+	admin.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	admin.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// 没有开启日志级别接口
+	recorder = httptest.NewRecorder()
+	admin.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/loglevel", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestEngine_ActiveConns(t *testing.T) {
+	app := NewEngine(WithShutdownSignals())
+	app.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "ok")
+	})
+	admin := NewAdminEngine(app, WithAdminEngineOptions(WithShutdownSignals()))
+	go func() {
+		_ = app.Start("127.0.0.1:0")
+	}()
+	go func() {
+		_ = admin.Start("127.0.0.1:0")
+	}()
+	defer func() {
+		_ = app.Shutdown(context.Background())
+		_ = admin.Shutdown(context.Background())
+	}()
+	require.Eventually(t, func() bool {
+		return app.Ready() && admin.Ready()
+	}, time.Second, 10*time.Millisecond)
+
+	conn, err := net.Dial("tcp", app.Addr().String())
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return app.ActiveConns() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	var info runtimeInfo
+	require.NoError(t, json.Unmarshal([]byte(getBody(t, http.DefaultClient, "http://"+admin.Addr().String()+"/debug/runtime")), &info))
+	assert.Equal(t, int64(1), info.ActiveConns)
+	assert.True(t, info.Ready)
+	assert.Greater(t, info.Goroutines, 0)
+
+	require.NoError(t, conn.Close())
+	assert.Eventually(t, func() bool {
+		return app.ActiveConns() == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,201 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	ErrBodyTooLarge = errors.New("request body too large")
+	ErrTrailingData = errors.New("trailing data after JSON value")
+)
+
+var _ StatusCoder = &BindError{}
+
+// BindError 解析请求体失败的错误
+type BindError struct {
+	Err error
+}
+
+func (e *BindError) Error() string {
+	return "bind: " + e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode 请求体过大返回 413，内容类型不支持返回 415，其他返回 400
+func (e *BindError) StatusCode() int {
+	if errors.Is(e.Err, ErrBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	if errors.Is(e.Err, ErrUnsupportedMediaType) {
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusBadRequest
+}
+
+// BindOption 解析 JSON 的可选项
+type BindOption func(opts *bindOptions)
+
+// bindOptions 解析 JSON 的配置
+type bindOptions struct {
+	disallowUnknownFields bool // 不允许未知字段
+	useNumber             bool // 数字解析为 json.Number
+	disallowTrailingData  bool // 不允许 JSON 值之后还有其他数据
+}
+
+// DisallowUnknownFields 不允许请求体中出现目标结构体没有的字段
+func DisallowUnknownFields() BindOption {
+	return func(opts *bindOptions) {
+		opts.disallowUnknownFields = true
+	}
+}
+
+// UseNumber 将数字解析为 json.Number 而不是 float64
+func UseNumber() BindOption {
+	return func(opts *bindOptions) {
+		opts.useNumber = true
+	}
+}
+
+// DisallowTrailingData 不允许 JSON 值之后还有除空白以外的数据
+func DisallowTrailingData() BindOption {
+	return func(opts *bindOptions) {
+		opts.disallowTrailingData = true
+	}
+}
+
+// WithBindOptions 设置引擎默认的 JSON 解析选项，BindJSON 传入的选项在此基础上叠加
+func WithBindOptions(opts ...BindOption) EngineOption {
+	return func(e *Engine) {
+		e.bindOptions = opts
+	}
+}
+
+// WithMaxBodyBytes 设置请求体的最大字节数，超出时返回 413
+func WithMaxBodyBytes(n int64) EngineOption {
+	return func(e *Engine) {
+		e.maxBodyBytes = n
+	}
+}
+
+// BodyLimitBuilder 路由级别的请求体大小限制中间件构建器，会覆盖 Engine 的限制
+type BodyLimitBuilder struct {
+	MaxBytes int64
+}
+
+// Build 构建请求体大小限制中间件
+func (b BodyLimitBuilder) Build() HandleFunc {
+	return func(ctx *Context) {
+		if !ctx.limitBodyBytes(b.MaxBytes) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// limitBodyBytes 限制请求体大小，Content-Length 或已经通过 Body 缓存的请求体超出限制时
+// 直接返回 413 并中止，返回 false
+func (c *Context) limitBodyBytes(n int64) bool {
+	if n > 0 && (c.Req.ContentLength > n || int64(len(c.bodyCache)) > n) {
+		c.AbortWithError(&BindError{Err: ErrBodyTooLarge})
+		return false
+	}
+	c.wrapBody(n)
+	return true
+}
+
+// wrapBody 使用 http.MaxBytesReader 包装请求体
+// 每次都基于原始请求体重新包装，使路由级别的限制可以大于 Engine 的限制；
+// 请求体已经通过 Body 读取时原始请求体已经读完，继续使用缓存
+func (c *Context) wrapBody(n int64) {
+	if n <= 0 || c.Req.Body == nil || c.Req.Body == http.NoBody {
+		return
+	}
+	if c.bodyCache != nil {
+		c.Req.Body = io.NopCloser(bytes.NewReader(c.bodyCache))
+		return
+	}
+	if c.rawBody == nil {
+		c.rawBody = c.Req.Body
+	}
+	c.Req.Body = http.MaxBytesReader(c.Resp, c.rawBody, n)
+}
+
+// bodyErr 将读取请求体的错误转换为 BindError
+func bodyErr(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &BindError{Err: ErrBodyTooLarge}
+	}
+	return &BindError{Err: err}
+}
+
+// Body 读取并缓存请求体，之后可以多次调用，也不影响 BindJSON 和直接读取 Req.Body
+// 适用于签名校验等需要在中间件中读取请求体的场景
+func (c *Context) Body() ([]byte, error) {
+	if c.bodyCache == nil {
+		if c.Req.Body == nil {
+			return nil, nil
+		}
+		data, err := io.ReadAll(c.Req.Body)
+		if err != nil {
+			return nil, bodyErr(err)
+		}
+		c.bodyCache = data
+	}
+	c.Req.Body = io.NopCloser(bytes.NewReader(c.bodyCache))
+	return c.bodyCache, nil
+}
+
+// BindJSON 解析JSON数据
+// opts 在引擎默认选项的基础上叠加，失败时返回 *BindError
+func (c *Context) BindJSON(val any, opts ...BindOption) error {
+	if val == nil {
+		return errors.New("nil pointer")
+	}
+	cfg := &bindOptions{}
+	if c.engine != nil {
+		for _, opt := range c.engine.bindOptions {
+			opt(cfg)
+		}
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if c.bodyCache != nil {
+		c.Req.Body = io.NopCloser(bytes.NewReader(c.bodyCache))
+	}
+	decoder := json.NewDecoder(c.Req.Body)
+	if cfg.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if cfg.useNumber {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(val); err != nil {
+		return bodyErr(err)
+	}
+	if cfg.disallowTrailingData {
+		var extra json.RawMessage
+		err := decoder.Decode(&extra)
+		if err == nil {
+			return &BindError{Err: ErrTrailingData}
+		}
+		if !errors.Is(err, io.EOF) {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return bodyErr(err)
+			}
+			return &BindError{Err: fmt.Errorf("%w: %v", ErrTrailingData, err)}
+		}
+	}
+	return nil
+}
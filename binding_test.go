@@ -0,0 +1,217 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindUser struct {
+	Name string `json:"name"`
+	Age  any    `json:"age"`
+}
+
+func TestContext_BindJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		opts     []BindOption
+		wantErr  error
+		wantUser bindUser
+	}{
+		{
+			name:     "ok",
+			body:     `{"name":"tom","age":18,"extra":1} garbage`,
+			wantUser: bindUser{Name: "tom", Age: float64(18)},
+		},
+		{
+			name:     "use number",
+			body:     `{"name":"tom","age":18}`,
+			opts:     []BindOption{UseNumber()},
+			wantUser: bindUser{Name: "tom", Age: json.Number("18")},
+		},
+		{
+			name:    "unknown field",
+			body:    `{"name":"tom","extra":1}`,
+			opts:    []BindOption{DisallowUnknownFields()},
+			wantErr: &BindError{},
+		},
+		{
+			name:    "trailing data",
+			body:    `{"name":"tom"} {"name":"jerry"}`,
+			opts:    []BindOption{DisallowTrailingData()},
+			wantErr: ErrTrailingData,
+		},
+		{
+			name:    "trailing garbage",
+			body:    `{"name":"tom"} garbage`,
+			opts:    []BindOption{DisallowTrailingData()},
+			wantErr: ErrTrailingData,
+		},
+		{
+			name:     "trailing whitespace",
+			body:     "{\"name\":\"tom\"}\n\t ",
+			opts:     []BindOption{DisallowTrailingData()},
+			wantUser: bindUser{Name: "tom"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			ctx := newContext(httptest.NewRecorder(), req)
+			var user bindUser
+			err := ctx.BindJSON(&user, tc.opts...)
+			if tc.wantErr != nil {
+				if _, ok := tc.wantErr.(*BindError); ok {
+					var bindErr *BindError
+					assert.ErrorAs(t, err, &bindErr)
+				} else {
+					assert.ErrorIs(t, err, tc.wantErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantUser, user)
+		})
+	}
+}
+
+func TestEngine_BodyLimit(t *testing.T) {
+	e := NewEngine(
+		WithMaxBodyBytes(16),
+		WithBindOptions(DisallowUnknownFields()),
+	)
+	handler := func(ctx *Context) {
+		var user bindUser
+		if err := ctx.BindJSON(&user); err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		_ = ctx.String(http.StatusOK, user.Name)
+	}
+	e.POST("/user", handler)
+	e.POST("/big", BodyLimitBuilder{MaxBytes: 1024}.Build(), handler)
+
+	testCases := []struct {
+		name          string
+		path          string
+		body          string
+		unknownLength bool
+		wantCode      int
+	}{
+		{name: "ok", path: "/user", body: `{"name":"tom"}`, wantCode: http.StatusOK},
+		{name: "content length too large", path: "/user", body: `{"name":"tom and jerry"}`,
+			wantCode: http.StatusRequestEntityTooLarge},
+		{name: "chunked too large", path: "/user", body: `{"name":"tom and jerry"}`, unknownLength: true,
+			wantCode: http.StatusRequestEntityTooLarge},
+		{name: "route limit", path: "/big", body: `{"name":"tom and jerry"}`, wantCode: http.StatusOK},
+		{name: "engine bind options", path: "/user", body: `{"x":1}`, wantCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			if tc.unknownLength {
+				req.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+		})
+	}
+}
+
+func TestContext_Body(t *testing.T) {
+	secret := []byte("secret")
+	e := NewEngine()
+	// 签名校验中间件读取请求体后，处理函数仍然可以解析
+	e.Use(func(ctx *Context) {
+		body, err := ctx.Body()
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		if hex.EncodeToString(mac.Sum(nil)) != ctx.Req.Header.Get("X-Signature") {
+			ctx.Status(http.StatusUnauthorized)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	})
+	e.POST("/user", func(ctx *Context) {
+		var user bindUser
+		if err := ctx.BindJSON(&user); err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		raw, _ := io.ReadAll(ctx.Req.Body)
+		_ = ctx.String(http.StatusOK, user.Name+" "+string(raw))
+	})
+
+	body := `{"name":"tom"}`
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(body))
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+	req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "tom ", recorder.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+	req.Header.Set("X-Signature", "bad")
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestContext_BodyThenRouteLimit(t *testing.T) {
+	e := NewEngine(WithMaxBodyBytes(64))
+	// 中间件先读取请求体，路由再设置大小限制
+	e.Use(func(ctx *Context) {
+		if _, err := ctx.Body(); err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		ctx.Next()
+	})
+	handler := func(ctx *Context) {
+		name, _ := ctx.FormValue("name")
+		_ = ctx.String(http.StatusOK, name)
+	}
+	e.POST("/big", BodyLimitBuilder{MaxBytes: 1024}.Build(), handler)
+	e.POST("/small", BodyLimitBuilder{MaxBytes: 8}.Build(), handler)
+
+	testCases := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "form after body", path: "/big", wantCode: http.StatusOK, wantBody: "tom"},
+		{name: "cached body too large", path: "/small", wantCode: http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader("name=tom&age=18"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.ContentLength = -1
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+			}
+		})
+	}
+}
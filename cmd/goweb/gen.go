@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// defaultGenDir gen 默认生成代码的目录，与 new 创建的项目结构一致
+var defaultGenDir = filepath.Join("internal", "handler")
+
+// handlerData 处理函数模板的数据
+type handlerData struct {
+	Package string
+	Name    string // 处理函数名
+}
+
+// groupData 路由组模板的数据
+type groupData struct {
+	Package  string
+	Plural   string // 资源名的复数形式，例如 Users
+	Resource string // 资源名，例如 User
+	Prefix   string // 路由组的路径前缀
+	Tag      string // OpenAPI 文档中的标签
+}
+
+// runGen 生成处理函数或路由组
+func runGen(args []string, out io.Writer) error {
+	const usage = "gen handler|group [-dir dir] <name>"
+	if len(args) == 0 || (args[0] != "handler" && args[0] != "group") {
+		fmt.Fprintln(os.Stderr, "Usage: goweb", usage)
+		return errUsage
+	}
+	kind := args[0]
+	fs := newFlagSet("gen "+kind, usage)
+	dir := fs.String("dir", defaultGenDir, "directory of the generated file")
+	prefix := fs.String("prefix", "", "path prefix of the group, defaults to /<name>")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	name := fs.Arg(0)
+	if !isIdentifier(name) {
+		return fmt.Errorf("%q is not a valid Go identifier", name)
+	}
+	pkg, err := packageName(*dir)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(*dir, snakeCase(name)+".go")
+	var hint string
+	if kind == "handler" {
+		err = writeTemplate(target, "gen_handler.go.tmpl", handlerData{Package: pkg, Name: exported(name)})
+	} else {
+		data := groupData{
+			Package: pkg,
+			Plural:  exported(name),
+			Prefix:  *prefix,
+			Tag:     strings.ToLower(name),
+		}
+		data.Resource = singular(data.Plural)
+		if data.Resource == data.Plural {
+			data.Plural = plural(data.Resource)
+		}
+		if data.Prefix == "" {
+			data.Prefix = "/" + strings.ToLower(data.Plural)
+		}
+		err = writeTemplate(target, "gen_group.go.tmpl", data)
+		hint = fmt.Sprintf("\nRegister the group, for example in handler.Register:\n  Register%s(api)\n", data.Plural)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "create", target)
+	fmt.Fprint(out, hint)
+	return nil
+}
+
+// isIdentifier 判断是否为合法的 Go 标识符
+func isIdentifier(name string) bool {
+	if name == "" || token.IsKeyword(name) {
+		return false
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// exported 将首字母转换为大写
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// singular 返回英文名词的单数形式，只处理常见的 s、es 和 ies 结尾
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "uses"),
+		strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// plural 返回英文名词的复数形式，只处理常见的规则
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// snakeCase 将驼峰命名转换为文件名使用的下划线命名，例如 GetUser 转换为 get_user
+func snakeCase(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// 连续的大写字母视为一个单词，例如 GetHTTPStatus 转换为 get_http_status
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// packageName 返回目录中已有 Go 文件的包名，没有 Go 文件时使用目录名
+func packageName(dir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		return f.Name.Name, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	name := strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, filepath.Base(abs)))
+	if !isIdentifier(name) {
+		return "", fmt.Errorf("cannot derive a package name from %s", dir)
+	}
+	return name, nil
+}
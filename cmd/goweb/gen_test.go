@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGen(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		existing string // 目录中已有的文件内容
+		wantFile string
+		wantPkg  string
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "handler",
+			args:     []string{"gen", "handler", "getUser"},
+			wantFile: "get_user.go",
+			wantPkg:  "handler",
+			want:     []string{"type GetUserReq struct", "func GetUser(ctx *web.Context, req GetUserReq) (*GetUserResp, error)"},
+		},
+		{
+			name:     "group",
+			args:     []string{"gen", "group", "users"},
+			wantFile: "users.go",
+			wantPkg:  "handler",
+			want: []string{
+				"func RegisterUsers(g web.IRouterGroup)",
+				`group := g.Group("/users")`,
+				`web.DocTags("users")`,
+				"func GetUser(ctx *web.Context, req GetUserReq) (*User, error)",
+			},
+		},
+		{
+			name:     "group with singular name",
+			args:     []string{"gen", "group", "-prefix", "/v1/categories", "category"},
+			wantFile: "category.go",
+			wantPkg:  "handler",
+			want:     []string{"func RegisterCategories(g web.IRouterGroup)", `g.Group("/v1/categories")`, "type Category struct"},
+		},
+		{
+			name:     "package of existing files",
+			args:     []string{"gen", "handler", "Login"},
+			existing: "package api\n",
+			wantFile: "login.go",
+			wantPkg:  "api",
+		},
+		{
+			name:    "invalid name",
+			args:    []string{"gen", "handler", "get-user"},
+			wantErr: `"get-user" is not a valid Go identifier`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "handler")
+			if tc.existing != "" {
+				require.NoError(t, os.MkdirAll(dir, 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.go"), []byte(tc.existing), 0o644))
+			}
+			args := append(tc.args[:2:2], append([]string{"-dir", dir}, tc.args[2:]...)...)
+			out := &bytes.Buffer{}
+			err := run(args, out, out)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			target := filepath.Join(dir, tc.wantFile)
+			assert.Contains(t, out.String(), "create "+target)
+			data, err := os.ReadFile(target)
+			require.NoError(t, err)
+			f, err := parser.ParseFile(token.NewFileSet(), target, data, parser.AllErrors)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPkg, f.Name.Name)
+			for _, want := range tc.want {
+				assert.Contains(t, string(data), want)
+			}
+
+			// 不覆盖已有的文件
+			assert.Error(t, run(args, out, out))
+		})
+	}
+}
+
+func TestNaming(t *testing.T) {
+	testCases := []struct {
+		name     string
+		singular string
+		plural   string
+		snake    string
+	}{
+		{name: "User", singular: "User", plural: "Users", snake: "user"},
+		{name: "Category", singular: "Category", plural: "Categories", snake: "category"},
+		{name: "Address", singular: "Address", plural: "Addresses", snake: "address"},
+		{name: "Box", singular: "Box", plural: "Boxes", snake: "box"},
+		{name: "Key", singular: "Key", plural: "Keys", snake: "key"},
+		{name: "GetHTTPStatus", singular: "GetHTTPStatus", plural: "GetHTTPStatuses", snake: "get_http_status"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.singular, singular(tc.name))
+			assert.Equal(t, tc.plural, plural(tc.singular))
+			assert.Equal(t, tc.singular, singular(tc.plural))
+			assert.Equal(t, tc.snake, snakeCase(tc.name))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Andras5014/go-web"
+	"github.com/Andras5014/go-web/openapi"
+)
+
+// defaultInspectTimeout 编译并运行应用的默认超时时间
+const defaultInspectTimeout = 2 * time.Minute
+
+// inspectFlags routes 和 openapi 共用的参数
+type inspectFlags struct {
+	timeout time.Duration
+	pkg     string   // 应用的包，默认为当前目录
+	args    []string // 传给应用的参数，-- 之后的部分
+}
+
+// parseInspect 解析参数，fs 中可以预先定义子命令自己的参数
+func parseInspect(fs *flag.FlagSet, args []string) (*inspectFlags, error) {
+	res := &inspectFlags{pkg: "."}
+	fs.DurationVar(&res.timeout, "timeout", defaultInspectTimeout, "timeout for building and running the application")
+	var appArgs []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, appArgs = args[:i], args[i+1:]
+			break
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		res.pkg = fs.Arg(0)
+	default:
+		fs.Usage()
+		return nil, errUsage
+	}
+	res.args = appArgs
+	return res, nil
+}
+
+// introspect 执行内省，测试中替换
+var introspect = runApp
+
+// runApp 通过 go run 运行应用并读取内省钩子的输出
+func runApp(ctx context.Context, mode string, f *inspectFlags) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "goweb")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, mode+".json")
+
+	cmd := exec.CommandContext(ctx, "go", append([]string{"run", f.pkg}, f.args...)...)
+	cmd.Env = append(os.Environ(), web.IntrospectEnv+"="+mode, web.IntrospectOutputEnv+"="+output)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s did not exit in time, does it create the engine with web.WithIntrospection?", f.pkg)
+		}
+		return nil, fmt.Errorf("go run %s: %w", f.pkg, err)
+	}
+	data, err := os.ReadFile(output)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s exited without output, does it create the engine with web.WithIntrospection and call Start or Run?", f.pkg)
+	}
+	return data, err
+}
+
+// runIntrospect 在超时时间内执行内省
+func runIntrospect(mode string, f *inspectFlags) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	return introspect(ctx, mode, f)
+}
+
+// runRoutes 输出应用的路由表
+func runRoutes(args []string, out io.Writer) error {
+	fs := newFlagSet("routes", "routes [-json] [-timeout d] [package] [-- args]")
+	asJSON := fs.Bool("json", false, "print routes as JSON")
+	f, err := parseInspect(fs, args)
+	if err != nil {
+		return err
+	}
+	data, err := runIntrospect(web.IntrospectRoutes, f)
+	if err != nil {
+		return err
+	}
+	var routes []web.RouteInfo
+	if err = json.Unmarshal(data, &routes); err != nil {
+		return err
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(routes)
+	}
+	return printRoutes(out, routes)
+}
+
+// printRoutes 以表格形式输出路由
+func printRoutes(out io.Writer, routes []web.RouteInfo) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tNAME\tHANDLER")
+	for _, r := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Method, r.Path, r.Name, r.Handler)
+	}
+	return w.Flush()
+}
+
+// runOpenAPI 根据应用的路由生成 OpenAPI 文档
+func runOpenAPI(args []string, out io.Writer) error {
+	fs := newFlagSet("openapi", "openapi [-o file] [-timeout d] [package] [-- args]")
+	output := fs.String("o", "", "output file, .json for JSON and YAML otherwise; defaults to stdout in YAML")
+	f, err := parseInspect(fs, args)
+	if err != nil {
+		return err
+	}
+	data, err := runIntrospect(web.IntrospectOpenAPI, f)
+	if err != nil {
+		return err
+	}
+	doc, err := openapi.Parse(data)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(*output), ".json") {
+		data, err = json.MarshalIndent(doc, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = doc.YAML()
+	}
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = out.Write(data)
+		return err
+	}
+	if err = os.WriteFile(*output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "create", *output)
+	return nil
+}
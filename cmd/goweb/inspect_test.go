@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Andras5014/go-web"
+	"github.com/Andras5014/go-web/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubIntrospect 替换内省函数，返回固定的输出并记录参数
+func stubIntrospect(t *testing.T, outputs map[string]string) *inspectFlags {
+	got := &inspectFlags{}
+	introspect = func(ctx context.Context, mode string, f *inspectFlags) ([]byte, error) {
+		*got = *f
+		return []byte(outputs[mode]), nil
+	}
+	t.Cleanup(func() {
+		introspect = runApp
+	})
+	return got
+}
+
+func TestRunRoutes(t *testing.T) {
+	got := stubIntrospect(t, map[string]string{
+		web.IntrospectRoutes: `[
+			{"method": "GET", "path": "/users", "handler": "main.listUsers"},
+			{"method": "GET", "path": "/users/:id", "name": "user", "handler": "main.getUser"}
+		]`,
+	})
+
+	out := &bytes.Buffer{}
+	require.NoError(t, run([]string{"routes", "-timeout", "1m", "./cmd/app", "--", "-config", "dev.yaml"}, out, out))
+	assert.Equal(t, "./cmd/app", got.pkg)
+	assert.Equal(t, time.Minute, got.timeout)
+	assert.Equal(t, []string{"-config", "dev.yaml"}, got.args)
+	assert.Equal(t, `METHOD  PATH        NAME  HANDLER
+GET     /users            main.listUsers
+GET     /users/:id  user  main.getUser
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, run([]string{"routes", "-json"}, out, out))
+	assert.Equal(t, ".", got.pkg)
+	assert.Empty(t, got.args)
+	assert.JSONEq(t, `[
+		{"method": "GET", "path": "/users", "handler": "main.listUsers"},
+		{"method": "GET", "path": "/users/:id", "name": "user", "handler": "main.getUser"}
+	]`, out.String())
+}
+
+func TestRunOpenAPI(t *testing.T) {
+	stubIntrospect(t, map[string]string{
+		web.IntrospectOpenAPI: `{"openapi": "3.1.0", "info": {"title": "app", "version": "1.0"}, "paths": {}}`,
+	})
+
+	out := &bytes.Buffer{}
+	require.NoError(t, run([]string{"openapi"}, out, out))
+	assert.Contains(t, out.String(), "openapi: 3.1.0\n")
+
+	dir := t.TempDir()
+	for _, name := range []string{"openapi.yaml", "openapi.json"} {
+		output := filepath.Join(dir, name)
+		out.Reset()
+		require.NoError(t, run([]string{"openapi", "-o", output}, out, out))
+		assert.Equal(t, "create "+output+"\n", out.String())
+		doc, err := openapi.Load(output)
+		require.NoError(t, err)
+		assert.Equal(t, "app", doc.Info.Title)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "openapi.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"openapi": "3.1.0"`)
+}
+
+func TestIntrospect_App(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds an application with go run")
+	}
+	out := &bytes.Buffer{}
+	require.NoError(t, run([]string{"routes", "./testdata/app"}, out, out))
+	assert.Contains(t, out.String(), "GET     /users/:id  user")
+
+	out.Reset()
+	require.NoError(t, run([]string{"openapi", "./testdata/app"}, out, out))
+	doc, err := openapi.Parse(out.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "app", doc.Info.Title)
+	require.Contains(t, doc.Paths, "/users/{id}")
+	assert.NotNil(t, doc.Paths["/users/{id}"].Get)
+}
@@ -0,0 +1,82 @@
+// Command goweb 创建和检查 go-web 应用
+//
+//	goweb new [-module path] <dir>          创建项目
+//	goweb gen handler [-dir dir] <Name>     生成处理函数
+//	goweb gen group [-dir dir] <name>       生成增删改查的路由组
+//	goweb routes [-json] [package] [-- args] 输出应用的路由表
+//	goweb openapi [-o file] [package] [-- args] 根据应用的路由生成 OpenAPI 文档
+//
+// routes 和 openapi 通过 go run 运行应用，应用需要在创建引擎时使用 web.WithIntrospection，
+// 并在 Start 或 Run 返回 web.ErrIntrospected 时正常退出
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errUsage 参数错误，已经输出了用法
+var errUsage = errors.New("invalid arguments")
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, out io.Writer) error
+}
+
+var commands = []command{
+	{name: "new", usage: "new [-module path] <dir>", run: runNew},
+	{name: "gen", usage: "gen handler|group [-dir dir] <name>", run: runGen},
+	{name: "routes", usage: "routes [-json] [-timeout d] [package] [-- args]", run: runRoutes},
+	{name: "openapi", usage: "openapi [-o file] [-timeout d] [package] [-- args]", run: runOpenAPI},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "goweb:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run 执行子命令
+func run(args []string, out io.Writer, errOut io.Writer) error {
+	if len(args) == 0 {
+		usage(errOut)
+		return errUsage
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], out)
+		}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(out)
+		return nil
+	}
+	usage(errOut)
+	return errUsage
+}
+
+// usage 输出所有子命令的用法
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	for _, c := range commands {
+		fmt.Fprintln(w, "  goweb", c.usage)
+	}
+}
+
+// newFlagSet 创建子命令的 FlagSet，解析失败时返回错误而不是退出
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goweb", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl", "templates/new/*.tmpl"))
+
+// projectFiles 新项目的文件，模板名 -> 相对路径
+var projectFiles = []struct {
+	template string
+	path     string
+}{
+	{"go.mod.tmpl", "go.mod"},
+	{"main.go.tmpl", "main.go"},
+	{"openapi.go.tmpl", "openapi.go"},
+	{"config.yaml.tmpl", "config.yaml"},
+	{"handler.go.tmpl", filepath.Join("internal", "handler", "handler.go")},
+}
+
+// projectData 新项目模板的数据
+type projectData struct {
+	Module    string // 模块路径
+	Name      string // 项目名，模块路径的最后一段
+	EnvPrefix string // 环境变量前缀
+}
+
+// runNew 创建项目
+func runNew(args []string, out io.Writer) error {
+	fs := newFlagSet("new", "new [-module path] <dir>")
+	module := fs.String("module", "", "module path, defaults to the base name of dir")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	dir := fs.Arg(0)
+	if *module == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		*module = filepath.Base(abs)
+	}
+	name := path.Base(*module)
+	data := projectData{Module: *module, Name: name, EnvPrefix: envPrefix(name)}
+
+	// 先检查所有文件，避免生成一半
+	for _, f := range projectFiles {
+		if _, err := os.Stat(filepath.Join(dir, f.path)); err == nil {
+			return fmt.Errorf("%s already exists", filepath.Join(dir, f.path))
+		}
+	}
+	for _, f := range projectFiles {
+		target := filepath.Join(dir, f.path)
+		if err := writeTemplate(target, f.template, data); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "create", target)
+	}
+	fmt.Fprintf(out, "\nNext steps:\n  cd %s\n  go mod tidy\n  go run .\n", dir)
+	return nil
+}
+
+// envPrefix 将项目名转换为环境变量前缀，例如 my-app 转换为 MY_APP
+func envPrefix(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
+
+// writeTemplate 渲染模板并写入文件，Go 文件会被格式化，文件已经存在时返回错误
+func writeTemplate(target string, name string, data any) error {
+	buf := &bytes.Buffer{}
+	if err := templates.ExecuteTemplate(buf, name, data); err != nil {
+		return err
+	}
+	content := buf.Bytes()
+	if strings.HasSuffix(target, ".go") {
+		var err error
+		if content, err = format.Source(content); err != nil {
+			return fmt.Errorf("%s: %w", target, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunNew(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-app")
+	out := &bytes.Buffer{}
+	require.NoError(t, run([]string{"new", "-module", "example.com/my-app", dir}, out, out))
+
+	for _, f := range projectFiles {
+		data, err := os.ReadFile(filepath.Join(dir, f.path))
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), filepath.Join(dir, f.path))
+		if filepath.Ext(f.path) == ".go" {
+			_, err = parser.ParseFile(token.NewFileSet(), f.path, data, parser.AllErrors)
+			assert.NoError(t, err, f.path)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "module example.com/my-app\n")
+	data, err = os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"example.com/my-app/internal/handler"`)
+	assert.Contains(t, string(data), `config.Load(*configPath, "MY_APP")`)
+
+	// 不覆盖已有的项目
+	err = run([]string{"new", dir}, out, out)
+	assert.EqualError(t, err, filepath.Join(dir, "go.mod")+" already exists")
+}
+
+func TestRunNew_DefaultModule(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "demo")
+	out := &bytes.Buffer{}
+	require.NoError(t, run([]string{"new", dir}, out, out))
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "module demo\n")
+}
+
+func TestRunNew_Usage(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.ErrorIs(t, run([]string{"new"}, out, out), errUsage)
+	assert.ErrorIs(t, run([]string{"unknown"}, out, out), errUsage)
+	assert.Contains(t, out.String(), "goweb new [-module path] <dir>")
+}
+
+func TestEnvPrefix(t *testing.T) {
+	assert.Equal(t, "MY_APP2", envPrefix("my-app2"))
+	assert.Equal(t, "DEMO", envPrefix("demo"))
+}
@@ -0,0 +1,30 @@
+// app 用于测试 goweb routes 和 goweb openapi 的应用
+package main
+
+import (
+	"errors"
+	"log"
+
+	"github.com/Andras5014/go-web"
+	"github.com/Andras5014/go-web/openapi"
+)
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type getUser struct {
+	ID int64 `path:"id"`
+}
+
+func main() {
+	e := web.NewEngine(web.WithIntrospection(web.WithOpenAPIInfo(openapi.Info{Title: "app", Version: "1.0"})))
+	e.GET("/users/:id", web.Typed(func(ctx *web.Context, req getUser) (*user, error) {
+		return &user{ID: req.ID}, nil
+	}))
+	e.NameRoute("user", "/users/:id")
+	if err := e.Start("127.0.0.1:0"); err != nil && !errors.Is(err, web.ErrIntrospected) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,359 @@
+// Package config 从 YAML、JSON、TOML 文件和环境变量加载 web.Engine 的配置
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Andras5014/go-web"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAddr 默认的监听地址
+const DefaultAddr = ":8080"
+
+// Duration 可以从 "1m30s" 形式的字符串解析的时间间隔
+type Duration time.Duration
+
+// UnmarshalText 解析时间间隔，YAML、JSON、TOML 和环境变量都使用该方法
+func (d *Duration) UnmarshalText(text []byte) error {
+	val, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(val)
+	return nil
+}
+
+// MarshalText 输出 "1m30s" 形式的字符串
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config 引擎和内置中间件的配置
+type Config struct {
+	Server     ServerConfig     `yaml:"server" json:"server" toml:"server"`
+	TLS        TLSConfig        `yaml:"tls" json:"tls" toml:"tls"`
+	Middleware MiddlewareConfig `yaml:"middleware" json:"middleware" toml:"middleware"`
+}
+
+// ServerConfig 服务器配置，零值表示使用引擎的默认值
+type ServerConfig struct {
+	Addr               string   `yaml:"addr" json:"addr" toml:"addr"`
+	ReadTimeout        Duration `yaml:"read_timeout" json:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout  Duration `yaml:"read_header_timeout" json:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout       Duration `yaml:"write_timeout" json:"write_timeout" toml:"write_timeout"`
+	IdleTimeout        Duration `yaml:"idle_timeout" json:"idle_timeout" toml:"idle_timeout"`
+	MaxHeaderBytes     int      `yaml:"max_header_bytes" json:"max_header_bytes" toml:"max_header_bytes"`
+	DisableKeepAlives  bool     `yaml:"disable_keep_alives" json:"disable_keep_alives" toml:"disable_keep_alives"`
+	ShutdownTimeout    Duration `yaml:"shutdown_timeout" json:"shutdown_timeout" toml:"shutdown_timeout"`
+	DrainDelay         Duration `yaml:"drain_delay" json:"drain_delay" toml:"drain_delay"`
+	H2C                bool     `yaml:"h2c" json:"h2c" toml:"h2c"`
+	TrustedProxies     []string `yaml:"trusted_proxies" json:"trusted_proxies" toml:"trusted_proxies"`
+	MaxBodyBytes       int64    `yaml:"max_body_bytes" json:"max_body_bytes" toml:"max_body_bytes"`
+	MaxMultipartMemory int64    `yaml:"max_multipart_memory" json:"max_multipart_memory" toml:"max_multipart_memory"`
+	MaxUploadSize      int64    `yaml:"max_upload_size" json:"max_upload_size" toml:"max_upload_size"`
+	RedirectAllowList  []string `yaml:"redirect_allow_list" json:"redirect_allow_list" toml:"redirect_allow_list"`
+	ProblemDetails     bool     `yaml:"problem_details" json:"problem_details" toml:"problem_details"`
+	MethodNotAllowed   bool     `yaml:"method_not_allowed" json:"method_not_allowed" toml:"method_not_allowed"`
+}
+
+// TLSConfig HTTPS 配置，CertFile 为空时不开启
+type TLSConfig struct {
+	CertFile      string   `yaml:"cert_file" json:"cert_file" toml:"cert_file"`
+	KeyFile       string   `yaml:"key_file" json:"key_file" toml:"key_file"`
+	ClientAuth    string   `yaml:"client_auth" json:"client_auth" toml:"client_auth"` // none、request、require、verify_if_given、require_and_verify
+	ClientCAFiles []string `yaml:"client_ca_files" json:"client_ca_files" toml:"client_ca_files"`
+}
+
+// Enabled 是否开启 HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// clientAuthTypes 客户端证书校验方式
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                   tls.NoClientCert,
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// MiddlewareConfig 内置中间件配置，按 Recover、Logger、Prometheus 的顺序注册
+type MiddlewareConfig struct {
+	Recover    RecoverConfig    `yaml:"recover" json:"recover" toml:"recover"`
+	Logger     LoggerConfig     `yaml:"logger" json:"logger" toml:"logger"`
+	Prometheus PrometheusConfig `yaml:"prometheus" json:"prometheus" toml:"prometheus"`
+}
+
+// RecoverConfig Recover 中间件配置
+type RecoverConfig struct {
+	Enabled  bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+	LogStack bool `yaml:"log_stack" json:"log_stack" toml:"log_stack"`
+}
+
+// LoggerConfig Logger 中间件配置
+type LoggerConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+}
+
+// PrometheusConfig Prometheus 中间件配置
+type PrometheusConfig struct {
+	Enabled   bool   `yaml:"enabled" json:"enabled" toml:"enabled"`
+	Namespace string `yaml:"namespace" json:"namespace" toml:"namespace"`
+	Subsystem string `yaml:"subsystem" json:"subsystem" toml:"subsystem"`
+	Name      string `yaml:"name" json:"name" toml:"name"`
+	Help      string `yaml:"help" json:"help" toml:"help"`
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{Addr: DefaultAddr},
+		Middleware: MiddlewareConfig{
+			Recover: RecoverConfig{Enabled: true},
+		},
+	}
+}
+
+// Load 在默认配置的基础上依次加载配置文件和环境变量，然后校验
+// path 为空时不加载文件，文件格式由扩展名决定：.yaml、.yml、.json 或 .toml，文件中不能有未知字段
+// envPrefix 为空时不加载环境变量，环境变量的规则见 LoadEnv
+func Load(path string, envPrefix string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if envPrefix != "" {
+		if err := cfg.LoadEnv(envPrefix); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadFile 从文件加载配置，文件中没有的字段保持原值
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+		if errors.Is(err, io.EOF) {
+			// 空文件
+			err = nil
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), c)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown fields %v", meta.Undecoded())
+		}
+	default:
+		return fmt.Errorf("config: unsupported file type %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate 校验配置，返回所有不合法的字段
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("config: %s: "+format, append([]any{field}, args...)...))
+	}
+
+	s := c.Server
+	if s.Addr == "" {
+		invalid("server.addr", "required")
+	} else if _, _, err := net.SplitHostPort(s.Addr); err != nil {
+		invalid("server.addr", "%v", err)
+	}
+	durations := map[string]Duration{
+		"server.read_timeout":        s.ReadTimeout,
+		"server.read_header_timeout": s.ReadHeaderTimeout,
+		"server.write_timeout":       s.WriteTimeout,
+		"server.idle_timeout":        s.IdleTimeout,
+		"server.shutdown_timeout":    s.ShutdownTimeout,
+		"server.drain_delay":         s.DrainDelay,
+	}
+	for field, d := range durations {
+		if d < 0 {
+			invalid(field, "must not be negative")
+		}
+	}
+	sizes := map[string]int64{
+		"server.max_header_bytes":     int64(s.MaxHeaderBytes),
+		"server.max_body_bytes":       s.MaxBodyBytes,
+		"server.max_multipart_memory": s.MaxMultipartMemory,
+		"server.max_upload_size":      s.MaxUploadSize,
+	}
+	for field, n := range sizes {
+		if n < 0 {
+			invalid(field, "must not be negative")
+		}
+	}
+	for _, p := range s.TrustedProxies {
+		if _, err := netip.ParsePrefix(p); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(p); err != nil {
+			invalid("server.trusted_proxies", "invalid IP or CIDR %q", p)
+		}
+	}
+
+	t := c.TLS
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		invalid("tls", "cert_file and key_file must be set together")
+	}
+	for _, file := range append([]string{t.CertFile, t.KeyFile}, t.ClientCAFiles...) {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			invalid("tls", "%v", err)
+		}
+	}
+	if _, ok := clientAuthTypes[t.ClientAuth]; !ok {
+		invalid("tls.client_auth", "unknown value %q", t.ClientAuth)
+	} else if t.ClientAuth != "" && t.ClientAuth != "none" && !t.Enabled() {
+		invalid("tls.client_auth", "requires cert_file")
+	}
+
+	if c.Middleware.Prometheus.Enabled && c.Middleware.Prometheus.Name == "" {
+		invalid("middleware.prometheus.name", "required")
+	}
+	return errors.Join(errs...)
+}
+
+// Options 将配置转换为 EngineOption，调用前应先通过 Validate 校验
+func (c *Config) Options() []web.EngineOption {
+	s := c.Server
+	var opts []web.EngineOption
+	durationOpts := []struct {
+		val Duration
+		opt func(time.Duration) web.EngineOption
+	}{
+		{s.ReadTimeout, web.WithReadTimeout},
+		{s.ReadHeaderTimeout, web.WithReadHeaderTimeout},
+		{s.WriteTimeout, web.WithWriteTimeout},
+		{s.IdleTimeout, web.WithIdleTimeout},
+		{s.ShutdownTimeout, web.WithShutdownTimeout},
+		{s.DrainDelay, web.WithDrainDelay},
+	}
+	for _, d := range durationOpts {
+		if d.val > 0 {
+			opts = append(opts, d.opt(time.Duration(d.val)))
+		}
+	}
+	if s.MaxHeaderBytes > 0 {
+		opts = append(opts, web.WithMaxHeaderBytes(s.MaxHeaderBytes))
+	}
+	if s.DisableKeepAlives {
+		opts = append(opts, web.WithKeepAlives(false))
+	}
+	if s.H2C {
+		opts = append(opts, web.WithH2C())
+	}
+	if len(s.TrustedProxies) > 0 {
+		opts = append(opts, web.WithTrustedProxies(s.TrustedProxies...))
+	}
+	if s.MaxBodyBytes > 0 {
+		opts = append(opts, web.WithMaxBodyBytes(s.MaxBodyBytes))
+	}
+	if s.MaxMultipartMemory > 0 {
+		opts = append(opts, web.WithMaxMultipartMemory(s.MaxMultipartMemory))
+	}
+	if s.MaxUploadSize > 0 {
+		opts = append(opts, web.WithMaxUploadSize(s.MaxUploadSize))
+	}
+	if len(s.RedirectAllowList) > 0 {
+		opts = append(opts, web.WithRedirectAllowList(s.RedirectAllowList...))
+	}
+	if s.ProblemDetails {
+		opts = append(opts, web.WithProblemDetails())
+	}
+	if s.MethodNotAllowed {
+		opts = append(opts, web.WithMethodNotAllowed())
+	}
+	if auth := clientAuthTypes[c.TLS.ClientAuth]; auth != tls.NoClientCert {
+		opts = append(opts, web.WithClientAuth(auth, c.TLS.ClientCAFiles...))
+	}
+	return opts
+}
+
+// Middlewares 返回开启的内置中间件
+func (c *Config) Middlewares() []web.HandleFunc {
+	m := c.Middleware
+	var res []web.HandleFunc
+	if m.Recover.Enabled {
+		res = append(res, web.RecoverBuilder{LogStack: m.Recover.LogStack}.Build())
+	}
+	if m.Logger.Enabled {
+		res = append(res, web.LoggerBuilder{}.Build())
+	}
+	if m.Prometheus.Enabled {
+		res = append(res, web.PrometheusBuilder{
+			Namespace: m.Prometheus.Namespace,
+			Subsystem: m.Prometheus.Subsystem,
+			Name:      m.Prometheus.Name,
+			Help:      m.Prometheus.Help,
+		}.Build())
+	}
+	return res
+}
+
+// NewEngine 校验配置并创建引擎，注册开启的内置中间件，opts 在配置生成的选项之后应用
+func (c *Config) NewEngine(opts ...web.EngineOption) (*web.Engine, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	e := web.NewEngine(append(c.Options(), opts...)...)
+	if mws := c.Middlewares(); len(mws) > 0 {
+		e.Use(mws...)
+	}
+	return e, nil
+}
+
+// Start 按配置启动引擎，收到退出信号后优雅关闭，见 Run
+func (c *Config) Start(e *web.Engine) error {
+	return c.Run(context.Background(), e)
+}
+
+// Run 按配置运行引擎，直到 ctx 结束或收到退出信号，然后按 shutdown_timeout 优雅关闭
+// 开启 HTTPS 时使用 RunTLS，证书文件修改后自动重新加载
+func (c *Config) Run(ctx context.Context, e *web.Engine) error {
+	if c.TLS.Enabled() {
+		return e.RunTLS(ctx, c.Server.Addr, c.TLS.CertFile, c.TLS.KeyFile)
+	}
+	return e.Run(ctx, c.Server.Addr)
+}
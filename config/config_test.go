@@ -0,0 +1,233 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Andras5014/go-web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile 在临时目录中写入配置文件
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	want := Default()
+	want.Server.Addr = "127.0.0.1:9090"
+	want.Server.ReadTimeout = Duration(5 * time.Second)
+	want.Server.IdleTimeout = Duration(time.Minute)
+	want.Server.TrustedProxies = []string{"10.0.0.0/8", "192.168.0.1"}
+	want.Server.MaxBodyBytes = 1 << 20
+	want.Server.ProblemDetails = true
+	want.Middleware.Recover.LogStack = true
+	want.Middleware.Logger.Enabled = true
+
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "app.yaml",
+			content: `
+server:
+  addr: 127.0.0.1:9090
+  read_timeout: 5s
+  idle_timeout: 1m
+  trusted_proxies: [10.0.0.0/8, 192.168.0.1]
+  max_body_bytes: 1048576
+  problem_details: true
+middleware:
+  recover:
+    enabled: true
+    log_stack: true
+  logger:
+    enabled: true
+`,
+		},
+		{
+			name: "json",
+			file: "app.json",
+			content: `{
+  "server": {
+    "addr": "127.0.0.1:9090",
+    "read_timeout": "5s",
+    "idle_timeout": "1m",
+    "trusted_proxies": ["10.0.0.0/8", "192.168.0.1"],
+    "max_body_bytes": 1048576,
+    "problem_details": true
+  },
+  "middleware": {"recover": {"log_stack": true}, "logger": {"enabled": true}}
+}`,
+		},
+		{
+			name: "toml",
+			file: "app.toml",
+			content: `
+[server]
+addr = "127.0.0.1:9090"
+read_timeout = "5s"
+idle_timeout = "1m"
+trusted_proxies = ["10.0.0.0/8", "192.168.0.1"]
+max_body_bytes = 1048576
+problem_details = true
+
+[middleware.recover]
+log_stack = true
+
+[middleware.logger]
+enabled = true
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := Load(writeFile(t, tc.file, tc.content), "")
+			require.NoError(t, err)
+			assert.Equal(t, want, cfg)
+		})
+	}
+}
+
+func TestLoad_Error(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown yaml field", file: "app.yaml", content: "server:\n  port: 80\n", wantErr: "field port not found"},
+		{name: "unknown json field", file: "app.json", content: `{"server": {"port": 80}}`, wantErr: `unknown field "port"`},
+		{name: "unknown toml field", file: "app.toml", content: "[server]\nport = 80\n", wantErr: "unknown fields [server.port]"},
+		{name: "invalid duration", file: "app.yaml", content: "server:\n  read_timeout: soon\n", wantErr: "invalid duration"},
+		{name: "unsupported type", file: "app.ini", content: "", wantErr: "unsupported file type"},
+		{name: "invalid addr", file: "app.yaml", content: "server:\n  addr: localhost\n", wantErr: "server.addr"},
+		{name: "invalid proxy", file: "app.yaml", content: "server:\n  trusted_proxies: [proxy]\n", wantErr: `invalid IP or CIDR "proxy"`},
+		{name: "negative timeout", file: "app.yaml", content: "server:\n  write_timeout: -1s\n", wantErr: "server.write_timeout: must not be negative"},
+		{name: "tls key missing", file: "app.yaml", content: "tls:\n  cert_file: a.crt\n", wantErr: "cert_file and key_file must be set together"},
+		{name: "client auth", file: "app.yaml", content: "tls:\n  client_auth: always\n", wantErr: `unknown value "always"`},
+		{name: "prometheus name", file: "app.yaml", content: "middleware:\n  prometheus:\n    enabled: true\n", wantErr: "middleware.prometheus.name: required"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeFile(t, tc.file, tc.content), "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestConfig_LoadEnv(t *testing.T) {
+	path := writeFile(t, "app.yaml", "server:\n  addr: :8081\n  read_timeout: 5s\n")
+	t.Setenv("APP_SERVER_ADDR", ":9000")
+	t.Setenv("APP_SERVER_WRITE_TIMEOUT", "10s")
+	t.Setenv("APP_SERVER_TRUSTED_PROXIES", "10.0.0.0/8, 172.16.0.1")
+	t.Setenv("APP_SERVER_MAX_UPLOAD_SIZE", "2048")
+	t.Setenv("APP_SERVER_H2C", "true")
+	t.Setenv("APP_MIDDLEWARE_RECOVER_ENABLED", "false")
+
+	cfg, err := Load(path, "app")
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, Duration(5*time.Second), cfg.Server.ReadTimeout)
+	assert.Equal(t, Duration(10*time.Second), cfg.Server.WriteTimeout)
+	assert.Equal(t, []string{"10.0.0.0/8", "172.16.0.1"}, cfg.Server.TrustedProxies)
+	assert.Equal(t, int64(2048), cfg.Server.MaxUploadSize)
+	assert.True(t, cfg.Server.H2C)
+	assert.False(t, cfg.Middleware.Recover.Enabled)
+
+	t.Setenv("APP_SERVER_MAX_BODY_BYTES", "large")
+	_, err = Load(path, "APP")
+	assert.ErrorContains(t, err, "APP_SERVER_MAX_BODY_BYTES")
+}
+
+func TestConfig_NewEngine(t *testing.T) {
+	cfg := Default()
+	cfg.Server.ReadTimeout = Duration(3 * time.Second)
+	cfg.Server.WriteTimeout = Duration(4 * time.Second)
+	cfg.Server.MaxHeaderBytes = 4096
+	cfg.Server.ProblemDetails = true
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8"}
+
+	e, err := cfg.NewEngine()
+	require.NoError(t, err)
+	srv := e.Server()
+	assert.Equal(t, 3*time.Second, srv.ReadTimeout)
+	assert.Equal(t, 4*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 4096, srv.MaxHeaderBytes)
+
+	e.GET("/panic", func(ctx *web.Context) {
+		panic("boom")
+	})
+	e.GET("/ip", func(ctx *web.Context) {
+		_ = ctx.String(http.StatusOK, ctx.ClientIP())
+	})
+	// Recover 中间件默认开启，并以 problem+json 响应
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, web.ProblemContentType, recorder.Header().Get("Content-Type"))
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, "203.0.113.7", recorder.Body.String())
+
+	cfg.Server.Addr = ""
+	_, err = cfg.NewEngine()
+	assert.Error(t, err)
+}
+
+func TestConfig_Run(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Addr = "127.0.0.1:0"
+	cfg.Server.ShutdownTimeout = Duration(100 * time.Millisecond)
+	e, err := cfg.NewEngine()
+	require.NoError(t, err)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	e.GET("/slow", func(ctx *web.Context) {
+		close(started)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- cfg.Run(ctx, e)
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	go func() {
+		resp, err := http.Get("http://" + e.Addr().String() + "/slow")
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// 请求一直没有完成，等待 shutdown_timeout 后放弃
+	start := time.Now()
+	cancel()
+	select {
+	case err = <-runErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, e.Ready())
+}
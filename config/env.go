@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LoadEnv 使用环境变量覆盖配置
+// 环境变量名由 prefix 和字段的 yaml 标签路径组成，全部大写，以下划线连接，
+// 例如 prefix 为 APP 时，server.read_timeout 对应 APP_SERVER_READ_TIMEOUT
+// 切片使用逗号分隔，例如 APP_SERVER_TRUSTED_PROXIES=10.0.0.0/8,192.168.0.1
+func (c *Config) LoadEnv(prefix string) error {
+	return loadEnv(reflect.ValueOf(c).Elem(), strings.ToUpper(prefix))
+}
+
+// textUnmarshalerType encoding.TextUnmarshaler 的类型
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// loadEnv 递归地使用环境变量设置结构体的字段
+func loadEnv(val reflect.Value, prefix string) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(tag)
+		fieldVal := val.Field(i)
+		if field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+			if err := loadEnv(fieldVal, name); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(fieldVal, raw); err != nil {
+			return fmt.Errorf("config: env %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField 将字符串解析后设置到字段
+func setField(val reflect.Value, raw string) error {
+	if u, ok := val.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch val.Kind() {
+	case reflect.String:
+		val.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		val.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetInt(n)
+	case reflect.Slice:
+		var parts []string
+		if raw != "" {
+			parts = strings.Split(raw, ",")
+		}
+		res := reflect.MakeSlice(val.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(res.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		val.Set(res)
+	default:
+		return fmt.Errorf("unsupported type %s", val.Type())
+	}
+	return nil
+}
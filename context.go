@@ -1,31 +1,53 @@
 package web
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"math"
-	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
 // abortIndex 定义中止索引值
 const abortIndex int = math.MaxInt8
 
+var _ context.Context = &Context{}
+
+// selfKey 用于判断一个 context.Context 是否由 *Context 派生
+type selfKey struct{}
+
 // Context 上下文结构体，包含了请求和响应相关信息
+// Context 实现了 context.Context，可以直接传给需要 context.Context 的下游库
+// Context 由 Engine 复用，请求处理结束后不能再使用；需要在 goroutine 中使用时请调用 Copy
 type Context struct {
 	Req          *http.Request       // HTTP请求
 	Resp         http.ResponseWriter // HTTP响应
 	PathParams   map[string]string   // 路径参数
-	queryCache   url.Values          // 查询缓存
+	queryCache   url.Values          // 查询缓存，复用时清空而不是重新分配
+	queryParsed  bool                // queryCache 是否已经解析了当前请求的查询参数
 	MatchedRoute string              // 匹配到的路由
-	Values       map[string]any
+	Values       map[string]any      // 通过 Set 设置的值
+	mu           sync.RWMutex        // 保护 Values
 
 	index    int          // 处理函数索引
 	handlers []HandleFunc // 处理函数列表
 
 	StatusCode int    // 响应状态码
 	RespData   []byte // 响应数据
+
+	written     bool          // 响应已直接写出或连接已被接管(例如文件下载、websocket)
+	engine      *Engine       // 处理当前请求的引擎
+	upload      *uploadConfig // 路由级别的上传限制
+	bodyLimited bool          // 请求体是否已经被限制大小
+	rawBody     io.ReadCloser // 限制大小之前的原始请求体
+	bodyCache   []byte        // 通过 Body 缓存的请求体
+	describe    *routeDoc     // 注册路由时收集文档信息，不为空时 Doc 和 Typed 处理函数只记录文档
 }
 
 // newContext 创建新的上下文实例
@@ -37,7 +59,84 @@ func newContext(w http.ResponseWriter, req *http.Request) *Context {
 	}
 }
 
+// NewContext 创建属于引擎的上下文，用于在路由之外执行处理函数，通常用于测试单个处理函数或中间件
+func (e *Engine) NewContext(w http.ResponseWriter, req *http.Request) *Context {
+	ctx := newContext(w, req)
+	ctx.engine = e
+	return ctx
+}
+
+// HandleContext 像匹配到路由一样依次执行 handlers 并发送响应，PathParams 等需要提前设置
+// 没有设置状态码时响应 200
+func (e *Engine) HandleContext(ctx *Context, handlers ...HandleFunc) {
+	ctx.engine = e
+	ctx.wrapBody(e.maxBodyBytes)
+	ctx.handlers = handlers
+	ctx.index = -1
+	ctx.Next()
+	if ctx.written {
+		return
+	}
+	if ctx.StatusCode == 0 {
+		ctx.StatusCode = http.StatusOK
+	}
+	e.flushResp(ctx)
+}
+
+// reset 重置上下文以便复用，保留已分配的 Values map
+func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
+	c.Req = req
+	c.Resp = w
+	c.PathParams = nil
+	clear(c.queryCache)
+	c.queryParsed = false
+	c.MatchedRoute = ""
+	c.mu.Lock()
+	clear(c.Values)
+	c.mu.Unlock()
+	c.index = -1
+	c.handlers = nil
+	c.StatusCode = 0
+	c.RespData = nil
+	c.written = false
+	c.engine = nil
+	c.upload = nil
+	c.bodyLimited = false
+	c.rawBody = nil
+	c.bodyCache = nil
+}
+
+// Copy 返回当前上下文的副本，副本可以在请求处理结束后安全地在 goroutine 中使用
+// 副本不能用于写响应，也不能继续执行处理函数链
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Req:          c.Req,
+		MatchedRoute: c.MatchedRoute,
+		index:        abortIndex,
+		StatusCode:   c.StatusCode,
+		engine:       c.engine,
+	}
+	if c.PathParams != nil {
+		cp.PathParams = make(map[string]string, len(c.PathParams))
+		for k, v := range c.PathParams {
+			cp.PathParams[k] = v
+		}
+	}
+	c.mu.RLock()
+	if len(c.Values) > 0 {
+		cp.Values = make(map[string]any, len(c.Values))
+		for k, v := range c.Values {
+			cp.Values[k] = v
+		}
+	}
+	c.mu.RUnlock()
+	return cp
+}
+
+// Get 获取通过 Set 设置的值
 func (c *Context) Get(key string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	if c.Values == nil {
 		return nil, false
 	}
@@ -45,13 +144,78 @@ func (c *Context) Get(key string) (any, bool) {
 	return val, ok
 }
 
+// Set 设置值，设置的值同样可以通过 context.Context 的 Value 方法获取
 func (c *Context) Set(key string, val any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.Values == nil {
 		c.Values = make(map[string]any)
 	}
 	c.Values[key] = val
 }
 
+// requestContext 返回请求的 context，请求为空时返回 context.Background()
+func (c *Context) requestContext() context.Context {
+	if c.Req == nil {
+		return context.Background()
+	}
+	return c.Req.Context()
+}
+
+// Deadline 实现 context.Context，返回请求 context 的截止时间
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.requestContext().Deadline()
+}
+
+// Done 实现 context.Context，请求 context 结束时关闭
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
+
+// Err 实现 context.Context，返回请求 context 结束的原因
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// Value 实现 context.Context
+// key 为 string 时优先查找通过 Set 设置的值，找不到再查找请求 context 中的值
+func (c *Context) Value(key any) any {
+	if key == (selfKey{}) {
+		return c
+	}
+	if k, ok := key.(string); ok {
+		if val, ok := c.Get(k); ok {
+			return val
+		}
+	}
+	return c.requestContext().Value(key)
+}
+
+// SetContext 替换请求的 context，后续中间件和处理函数都会使用新的 context
+// 新的 context 应当由 ctx.Req.Context() 派生，否则会丢失取消信号；
+// 由 *Context 本身派生的 context 会造成循环引用，因此会 panic
+func (c *Context) SetContext(ctx context.Context) {
+	if ctx == nil {
+		panic("nil context")
+	}
+	if ctx.Value(selfKey{}) == c {
+		panic("context derived from *web.Context, derive it from ctx.Req.Context() instead")
+	}
+	c.Req = c.Req.WithContext(ctx)
+}
+
+// WithValue 在请求 context 中设置值，适用于非 string 类型的 key
+func (c *Context) WithValue(key any, val any) {
+	c.SetContext(context.WithValue(c.requestContext(), key, val))
+}
+
+// WithTimeout 为请求 context 设置超时时间，返回的 cancel 需要在处理结束后调用
+func (c *Context) WithTimeout(timeout time.Duration) context.CancelFunc {
+	ctx, cancel := context.WithTimeout(c.requestContext(), timeout)
+	c.SetContext(ctx)
+	return cancel
+}
+
 func (c *Context) Status(status int) {
 	c.StatusCode = status
 }
@@ -84,6 +248,21 @@ func (c *Context) HTML(status int, val string) error {
 	return nil
 }
 
+// Render 使用引擎的模板引擎渲染名为 name 的模板并发送HTML响应
+func (c *Context) Render(status int, name string, data any) error {
+	if c.engine == nil || c.engine.templateEngine == nil {
+		return errors.New("template engine not configured")
+	}
+	buf := &bytes.Buffer{}
+	if err := c.engine.templateEngine.Render(buf, name, data); err != nil {
+		return err
+	}
+	c.Resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.StatusCode = status
+	c.RespData = buf.Bytes()
+	return nil
+}
+
 // JsonOK 发送HTTP OK状态的JSON响应
 func (c *Context) JsonOK(val any) error {
 	return c.JSON(http.StatusOK, val)
@@ -103,14 +282,6 @@ func (c *Context) GetCookie(name string) (*http.Cookie, bool) {
 	return cookie, true
 }
 
-// BindJSON 解析JSON数据
-func (c *Context) BindJSON(val any) error {
-	if val == nil {
-		return errors.New("nil pointer")
-	}
-	return json.NewDecoder(c.Req.Body).Decode(val)
-}
-
 // Param 获取路径参数
 func (c *Context) Param(key string) string {
 	return c.PathParams[key]
@@ -129,27 +300,46 @@ func (c *Context) FormValue(key string) (string, bool) {
 	return vals[0], true
 }
 
-// MultipartForm 获取Multipart表单
-func (c *Context) MultipartForm() (*multipart.Form, error) {
-	err := c.Req.ParseMultipartForm(32 << 20)
-	if err != nil {
-		return nil, err
-	}
-	return c.Req.MultipartForm, nil
-}
-
 // QueryValue 获取查询参数值
 func (c *Context) QueryValue(key string) (string, bool) {
-	if c.queryCache == nil {
-		c.queryCache = c.Req.URL.Query()
-	}
-	vals, ok := c.queryCache[key]
+	vals, ok := c.query()[key]
 	if !ok {
 		return "", false
 	}
 	return vals[0], true
 }
 
+// query 返回解析后的查询参数，解析结果写入复用的 queryCache
+func (c *Context) query() url.Values {
+	if c.queryParsed {
+		return c.queryCache
+	}
+	c.queryParsed = true
+	if c.queryCache == nil {
+		c.queryCache = make(url.Values)
+	}
+	// 与 url.ParseQuery 相同，跳过无法解析的键值对
+	query := c.Req.URL.RawQuery
+	for query != "" {
+		var pair string
+		pair, query, _ = strings.Cut(query, "&")
+		if pair == "" || strings.Contains(pair, ";") {
+			continue
+		}
+		key, val, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			continue
+		}
+		val, err = url.QueryUnescape(val)
+		if err != nil {
+			continue
+		}
+		c.queryCache[key] = append(c.queryCache[key], val)
+	}
+	return c.queryCache
+}
+
 // PathValue 获取路径参数值
 func (c *Context) PathValue(key string) (string, bool) {
 	res, ok := c.PathParams[key]
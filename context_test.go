@@ -0,0 +1,133 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+func TestContext_ContextValue(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, "from request"))
+	ctx := newContext(httptest.NewRecorder(), req)
+
+	ctx.Set("user", "tom")
+	// 下游库只能拿到 context.Context
+	var std context.Context = ctx
+	assert.Equal(t, "tom", std.Value("user"))
+	assert.Equal(t, "from request", std.Value(ctxKey{}))
+	assert.Nil(t, std.Value("missing"))
+
+	// 派生的 context 也能拿到值
+	derived, cancel := context.WithTimeout(std, time.Second)
+	defer cancel()
+	assert.Equal(t, "tom", derived.Value("user"))
+
+	ctx.WithValue(ctxKey{}, "replaced")
+	assert.Equal(t, "replaced", ctx.Value(ctxKey{}))
+	assert.Equal(t, "replaced", ctx.Req.Context().Value(ctxKey{}))
+}
+
+func TestContext_ContextCancel(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(parent)
+	ctx := newContext(httptest.NewRecorder(), req)
+
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	assert.NoError(t, ctx.Err())
+
+	cancelTimeout := ctx.WithTimeout(time.Hour)
+	defer cancelTimeout()
+	_, ok = ctx.Deadline()
+	assert.True(t, ok)
+
+	cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not canceled")
+	}
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestContext_SetContext(t *testing.T) {
+	e := NewEngine()
+	e.Use(func(ctx *Context) {
+		ctx.SetContext(context.WithValue(ctx.Req.Context(), ctxKey{}, "middleware"))
+		ctx.Next()
+	})
+	var got any
+	e.GET("/", func(ctx *Context) {
+		got = ctx.Value(ctxKey{})
+		ctx.Status(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "middleware", got)
+
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Panics(t, func() {
+		ctx.SetContext(context.WithValue(ctx, ctxKey{}, "loop"))
+	})
+}
+
+func TestContext_Pool(t *testing.T) {
+	e := NewEngine()
+	e.GET("/set/:name", func(ctx *Context) {
+		_, ok := ctx.Get("name")
+		assert.False(t, ok, "pooled context must be reset")
+		ctx.Set("name", ctx.Param("name"))
+		ctx.Status(http.StatusOK)
+	})
+	e.GET("/query", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, strings.Join(ctx.QueryArray("id"), ","))
+	})
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/set/tom", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+	// 复用的查询缓存不能包含上一个请求的参数
+	for _, target := range []string{"/query?id=1&id=2", "/query", "/query?id=3&name=a%20b&bad=%zz"} {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		e.ServeHTTP(recorder, req)
+		assert.Equal(t, strings.Join(req.URL.Query()["id"], ","), recorder.Body.String())
+	}
+}
+
+func TestContext_Copy(t *testing.T) {
+	e := NewEngine()
+	results := make(chan string, 100)
+	e.GET("/user/:id", func(ctx *Context) {
+		ctx.Set("id", ctx.Param("id"))
+		cp := ctx.Copy()
+		go func() {
+			// 请求结束后原上下文会被复用，副本中的值不受影响
+			time.Sleep(5 * time.Millisecond)
+			val, _ := cp.Get("id")
+			results <- cp.Param("id") + "=" + val.(string)
+		}()
+		ctx.Status(http.StatusOK)
+	})
+
+	for i := 0; i < 100; i++ {
+		id := strconv.Itoa(i)
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user/"+id, nil))
+	}
+	for i := 0; i < 100; i++ {
+		res := <-results
+		parts := strings.Split(res, "=")
+		require.Len(t, parts, 2)
+		assert.Equal(t, parts[0], parts[1])
+	}
+}
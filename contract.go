@@ -0,0 +1,396 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Andras5014/go-web/openapi"
+)
+
+// ValidationIssue 请求或响应不符合 OpenAPI 文档的一处错误
+type ValidationIssue struct {
+	In      string `json:"in"`                // 出错的位置：path、query、header、cookie 或 body
+	Name    string `json:"name,omitempty"`    // 参数名
+	Pointer string `json:"pointer,omitempty"` // 请求体或响应体中出错位置的 JSON Pointer
+	Message string `json:"message"`
+}
+
+// 校验请求体和响应体时的位置
+const (
+	issueInBody     = "body"
+	issueInResponse = "response"
+)
+
+// ignoredHeaders OpenAPI 规定这些请求头参数定义会被忽略
+var ignoredHeaders = map[string]bool{"Accept": true, "Content-Type": true, "Authorization": true}
+
+// OpenAPIValidatorBuilder 根据 OpenAPI 文档校验请求的中间件构建器
+// 通过匹配到的路由找到文档中的接口，路径参数按位置对应，名字可以与路由不同；
+// 校验路径参数、查询参数、请求头、Cookie 和请求体，不符合时以 problem+json 响应 400，
+// 请求体的内容类型不在文档中时响应 415
+type OpenAPIValidatorBuilder struct {
+	Doc              *openapi.Document
+	BasePath         string // 文档中的路径相对于该前缀，例如 /api/v1
+	ValidateResponse bool   // 是否校验响应，不符合时替换为 500，通常只在测试中开启
+	RejectUnknown    bool   // 文档中没有对应接口的请求是否响应 404 或 405，默认直接放行
+}
+
+// contractPath 文档中的一个路径
+type contractPath struct {
+	item   *openapi.PathItem
+	params []string // 路径参数名，按在路径中出现的顺序
+}
+
+// templateKey 将路径模板转换为与参数名无关的形式，{id} 和 :id 都转换为 {}
+func templateKey(p string) (string, []string) {
+	segs := strings.Split(p, "/")
+	var params []string
+	for i, seg := range segs {
+		switch {
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			params = append(params, seg[1:len(seg)-1])
+			segs[i] = "{}"
+		case strings.HasPrefix(seg, ":"):
+			params = append(params, seg[1:])
+			segs[i] = "{}"
+		}
+	}
+	return strings.Join(segs, "/"), params
+}
+
+// Build 构建 OpenAPI 校验中间件，Doc 为空时 panic
+func (b OpenAPIValidatorBuilder) Build() HandleFunc {
+	if b.Doc == nil {
+		panic("web: OpenAPIValidatorBuilder requires Doc")
+	}
+	paths := make(map[string]contractPath, len(b.Doc.Paths))
+	for p, item := range b.Doc.Paths {
+		key, params := templateKey(path.Join("/", b.BasePath, p))
+		paths[key] = contractPath{item: item, params: params}
+	}
+	return func(ctx *Context) {
+		key, routeNames := templateKey(ctx.MatchedRoute)
+		cp, ok := paths[key]
+		var op *openapi.Operation
+		if ok {
+			op = cp.item.Operation(ctx.Req.Method)
+		}
+		if op == nil {
+			if b.RejectUnknown && ctx.MatchedRoute != "" {
+				status := http.StatusNotFound
+				if ok {
+					status = http.StatusMethodNotAllowed
+				}
+				ctx.Abort()
+				_ = ctx.Problem(NewProblem(status, "operation is not defined in the API contract"))
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		v := &contractValidator{doc: b.Doc, ctx: ctx}
+		pathValues := make(map[string]string, len(cp.params))
+		for i, name := range cp.params {
+			if i < len(routeNames) {
+				pathValues[name] = ctx.PathParams[routeNames[i]]
+			}
+		}
+		v.validateParams(cp.item, op, pathValues)
+		if err := v.validateRequestBody(op); err != nil {
+			ctx.Abort()
+			_ = ctx.Problem(problemFromError(err))
+			return
+		}
+		if len(v.issues) > 0 {
+			ctx.Abort()
+			_ = ctx.Problem(NewProblem(http.StatusBadRequest, "request does not match the API contract").
+				With("errors", v.issues))
+			return
+		}
+
+		ctx.Next()
+		if !b.ValidateResponse || ctx.written {
+			return
+		}
+		if v.validateResponse(op); len(v.issues) > 0 {
+			ctx.Resp.Header().Del("Content-Length")
+			_ = ctx.Problem(NewProblem(http.StatusInternalServerError, "response does not match the API contract").
+				With("errors", v.issues))
+		}
+	}
+}
+
+// contractValidator 一次请求的校验状态
+type contractValidator struct {
+	doc    *openapi.Document
+	ctx    *Context
+	issues []ValidationIssue
+}
+
+// addSchemaErr 将 Schema 校验错误转换为 ValidationIssue
+func (v *contractValidator) addSchemaErr(in, name string, err error) {
+	var errs openapi.SchemaErrors
+	var single *openapi.SchemaError
+	switch {
+	case errors.As(err, &errs):
+	case errors.As(err, &single):
+		errs = openapi.SchemaErrors{single}
+	default:
+		v.issues = append(v.issues, ValidationIssue{In: in, Name: name, Message: err.Error()})
+		return
+	}
+	for _, e := range errs {
+		v.issues = append(v.issues, ValidationIssue{In: in, Name: name, Pointer: e.Path, Message: e.Message})
+	}
+}
+
+// parameters 合并路径和接口上的参数，接口上的参数覆盖同名同位置的路径参数
+func (v *contractValidator) parameters(item *openapi.PathItem, op *openapi.Operation) []*openapi.Parameter {
+	var res []*openapi.Parameter
+	index := make(map[string]int)
+	for _, list := range [][]*openapi.Parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			resolved, err := v.doc.ResolveParameter(p)
+			if err != nil {
+				v.issues = append(v.issues, ValidationIssue{In: p.In, Name: p.Name, Message: err.Error()})
+				continue
+			}
+			key := resolved.In + ":" + resolved.Name
+			if i, ok := index[key]; ok {
+				res[i] = resolved
+				continue
+			}
+			index[key] = len(res)
+			res = append(res, resolved)
+		}
+	}
+	return res
+}
+
+// paramValues 按位置获取参数值
+func (v *contractValidator) paramValues(p *openapi.Parameter, pathValues map[string]string) []string {
+	switch p.In {
+	case openapi.InPath:
+		if val, ok := pathValues[p.Name]; ok {
+			return []string{val}
+		}
+	case openapi.InQuery:
+		return v.ctx.QueryArray(p.Name)
+	case openapi.InHeader:
+		return v.ctx.Req.Header.Values(p.Name)
+	case openapi.InCookie:
+		if cookie, ok := v.ctx.GetCookie(p.Name); ok {
+			return []string{cookie.Value}
+		}
+	}
+	return nil
+}
+
+// validateParams 校验参数
+func (v *contractValidator) validateParams(item *openapi.PathItem, op *openapi.Operation, pathValues map[string]string) {
+	for _, p := range v.parameters(item, op) {
+		if p.In == openapi.InHeader && ignoredHeaders[http.CanonicalHeaderKey(p.Name)] {
+			continue
+		}
+		vals := v.paramValues(p, pathValues)
+		if len(vals) == 0 {
+			if p.Required || p.In == openapi.InPath {
+				v.issues = append(v.issues, ValidationIssue{In: p.In, Name: p.Name, Message: "parameter is required"})
+			}
+			continue
+		}
+		if p.Schema == nil {
+			continue
+		}
+		val, err := v.doc.Coerce(p.Schema, vals)
+		if err == nil {
+			err = v.doc.Validate(p.Schema, val)
+		}
+		if err != nil {
+			v.addSchemaErr(p.In, p.Name, err)
+		}
+	}
+}
+
+// matchContent 查找内容类型对应的定义，支持 type/* 和 */*
+func matchContent(content map[string]*openapi.MediaType, mediaType string) (*openapi.MediaType, bool) {
+	if mt, ok := content[mediaType]; ok {
+		return mt, true
+	}
+	if i := strings.Index(mediaType, "/"); i > 0 {
+		if mt, ok := content[mediaType[:i]+"/*"]; ok {
+			return mt, true
+		}
+	}
+	mt, ok := content["*/*"]
+	return mt, ok
+}
+
+// isJSONMediaType 判断是否为 JSON 内容类型
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == MIMEJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeJSON 解析 JSON，数字解析为 json.Number 以保留整数
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var res any
+	if err := decoder.Decode(&res); err != nil {
+		return nil, err
+	}
+	// More 只能发现紧跟的值，{"a":1} xyz 这样的非法数据需要再解码一次才能发现
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		return nil, ErrTrailingData
+	}
+	return res, nil
+}
+
+// validateRequestBody 校验请求体，读取请求体失败或内容类型不支持时返回错误
+func (v *contractValidator) validateRequestBody(op *openapi.Operation) error {
+	if op.RequestBody == nil {
+		return nil
+	}
+	body, err := v.doc.ResolveRequestBody(op.RequestBody)
+	if err != nil {
+		v.issues = append(v.issues, ValidationIssue{In: issueInBody, Message: err.Error()})
+		return nil
+	}
+	if !v.ctx.hasBody() {
+		if body.Required {
+			v.issues = append(v.issues, ValidationIssue{In: issueInBody, Message: "request body is required"})
+		}
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(v.ctx.GetHeader("Content-Type"))
+	if err != nil {
+		return &BindError{Err: ErrUnsupportedMediaType}
+	}
+	mt, ok := matchContent(body.Content, mediaType)
+	if !ok {
+		return &BindError{Err: ErrUnsupportedMediaType}
+	}
+	if mt == nil || mt.Schema == nil {
+		return nil
+	}
+
+	var val any
+	switch {
+	case isJSONMediaType(mediaType):
+		data, err := v.ctx.Body()
+		if err != nil {
+			return err
+		}
+		if val, err = decodeJSON(data); err != nil {
+			v.issues = append(v.issues, ValidationIssue{In: issueInBody, Message: "invalid JSON: " + err.Error()})
+			return nil
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		data, err := v.ctx.Body()
+		if err != nil {
+			return err
+		}
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			v.issues = append(v.issues, ValidationIssue{In: issueInBody, Message: "invalid form: " + err.Error()})
+			return nil
+		}
+		if val, err = v.formValue(mt.Schema, form); err != nil {
+			v.addSchemaErr(issueInBody, "", err)
+			return nil
+		}
+	default:
+		// 其他内容类型只校验类型本身
+		return nil
+	}
+	if err = v.doc.Validate(mt.Schema, val); err != nil {
+		v.addSchemaErr(issueInBody, "", err)
+	}
+	return nil
+}
+
+// formValue 按照 Schema 中属性的类型转换表单值
+func (v *contractValidator) formValue(s *openapi.Schema, form url.Values) (map[string]any, error) {
+	s, err := v.doc.ResolveSchema(s)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]any, len(form))
+	for name, vals := range form {
+		val, err := v.doc.Coerce(s.Properties[name], vals)
+		if err != nil {
+			return nil, &openapi.SchemaError{Path: "/" + name, Message: err.Error()}
+		}
+		res[name] = val
+	}
+	return res, nil
+}
+
+// findResponse 查找状态码对应的响应定义，依次查找 200、2XX 和 default
+func findResponse(responses map[string]*openapi.Response, status int) (*openapi.Response, bool) {
+	for _, key := range []string{strconv.Itoa(status), fmt.Sprintf("%dXX", status/100), "default"} {
+		if resp, ok := responses[key]; ok {
+			return resp, true
+		}
+	}
+	return nil, false
+}
+
+// validateResponse 校验响应
+func (v *contractValidator) validateResponse(op *openapi.Operation) {
+	status := v.ctx.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp, ok := findResponse(op.Responses, status)
+	if !ok {
+		v.issues = append(v.issues, ValidationIssue{
+			In:      issueInResponse,
+			Message: fmt.Sprintf("status %d is not documented", status),
+		})
+		return
+	}
+	resp, err := v.doc.ResolveResponse(resp)
+	if err != nil {
+		v.issues = append(v.issues, ValidationIssue{In: issueInResponse, Message: err.Error()})
+		return
+	}
+	if len(v.ctx.RespData) == 0 || len(resp.Content) == 0 {
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(v.ctx.Resp.Header().Get("Content-Type"))
+	if err != nil {
+		v.issues = append(v.issues, ValidationIssue{In: issueInResponse, Message: "missing or invalid Content-Type"})
+		return
+	}
+	mt, ok := matchContent(resp.Content, mediaType)
+	if !ok {
+		v.issues = append(v.issues, ValidationIssue{
+			In:      issueInResponse,
+			Message: fmt.Sprintf("content type %s is not documented", mediaType),
+		})
+		return
+	}
+	if mt == nil || mt.Schema == nil || !isJSONMediaType(mediaType) {
+		return
+	}
+	val, err := decodeJSON(v.ctx.RespData)
+	if err != nil {
+		v.issues = append(v.issues, ValidationIssue{In: issueInResponse, Message: "invalid JSON: " + err.Error()})
+		return
+	}
+	if err = v.doc.Validate(mt.Schema, val); err != nil {
+		v.addSchemaErr(issueInResponse, "", err)
+	}
+}
@@ -0,0 +1,292 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Andras5014/go-web/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const contractSpec = `
+openapi: 3.1.0
+info: {title: pets, version: "1"}
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, schema: {type: integer, maximum: 100}}
+        - {name: X-Request-Id, in: header, required: true, schema: {type: string, format: uuid}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: "#/components/schemas/Pet"}}
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/Pet"}
+          application/x-www-form-urlencoded:
+            schema: {$ref: "#/components/schemas/Pet"}
+      responses:
+        "2XX": {description: created}
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, required: true, schema: {type: integer, minimum: 1}}
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id: {type: integer}
+        name: {type: string, minLength: 1}
+`
+
+func newContractEngine(t *testing.T, b OpenAPIValidatorBuilder) *Engine {
+	doc, err := openapi.Parse([]byte(contractSpec))
+	require.NoError(t, err)
+	b.Doc = doc
+	e := NewEngine()
+	api := e.Group("/api")
+	api.Use(b.Build())
+	api.GET("/pets", func(ctx *Context) {
+		_ = ctx.JSON(http.StatusOK, []map[string]any{{"id": 1, "name": "tom"}})
+	})
+	api.POST("/pets", func(ctx *Context) {
+		ctx.Status(http.StatusCreated)
+	})
+	api.GET("/pets/:id", func(ctx *Context) {
+		if ctx.PathParams["id"] == "2" {
+			_ = ctx.JSON(http.StatusOK, map[string]any{"id": 2})
+			return
+		}
+		_ = ctx.JSON(http.StatusOK, map[string]any{"id": 1, "name": "tom"})
+	})
+	api.GET("/unknown", func(ctx *Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+	return e
+}
+
+func TestOpenAPIValidatorBuilder_Build(t *testing.T) {
+	const requestID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	e := newContractEngine(t, OpenAPIValidatorBuilder{BasePath: "/api"})
+
+	testCases := []struct {
+		name        string
+		method      string
+		target      string
+		header      http.Header
+		body        string
+		wantCode    int
+		wantIssues  []ValidationIssue
+		contentType string
+	}{
+		{
+			name:     "valid query",
+			method:   http.MethodGet,
+			target:   "/api/pets?limit=10",
+			header:   http.Header{"X-Request-Id": {requestID}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "invalid query",
+			method:   http.MethodGet,
+			target:   "/api/pets?limit=abc",
+			header:   http.Header{"X-Request-Id": {requestID}},
+			wantCode: http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "query", Name: "limit", Message: `expected integer, got "abc"`},
+			},
+		},
+		{
+			name:     "query out of range and missing header",
+			method:   http.MethodGet,
+			target:   "/api/pets?limit=1000",
+			wantCode: http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "query", Name: "limit", Message: "value must be <= 100"},
+				{In: "header", Name: "X-Request-Id", Message: "parameter is required"},
+			},
+		},
+		{
+			name:     "invalid header",
+			method:   http.MethodGet,
+			target:   "/api/pets",
+			header:   http.Header{"X-Request-Id": {"abc"}},
+			wantCode: http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "header", Name: "X-Request-Id", Message: "value is not a valid uuid"},
+			},
+		},
+		{
+			name:     "path param mapped by position",
+			method:   http.MethodGet,
+			target:   "/api/pets/0",
+			wantCode: http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "path", Name: "petId", Message: "value must be >= 1"},
+			},
+		},
+		{
+			name:        "valid json body",
+			method:      http.MethodPost,
+			target:      "/api/pets",
+			contentType: MIMEJSON,
+			body:        `{"id": 1, "name": "tom"}`,
+			wantCode:    http.StatusCreated,
+		},
+		{
+			name:        "invalid json body",
+			method:      http.MethodPost,
+			target:      "/api/pets",
+			contentType: MIMEJSON,
+			body:        `{"id": "1", "name": ""}`,
+			wantCode:    http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "body", Pointer: "/id", Message: "expected integer, got string"},
+				{In: "body", Pointer: "/name", Message: "length must be at least 1"},
+			},
+		},
+		{
+			name:        "malformed json body",
+			method:      http.MethodPost,
+			target:      "/api/pets",
+			contentType: MIMEJSON,
+			body:        `{"name"`,
+			wantCode:    http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "body", Message: "invalid JSON: unexpected EOF"},
+			},
+		},
+		{
+			name:        "trailing data after json body",
+			method:      http.MethodPost,
+			target:      "/api/pets",
+			contentType: MIMEJSON,
+			body:        `{"id": 1, "name": "tom"} xyz`,
+			wantCode:    http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "body", Message: "invalid JSON: " + ErrTrailingData.Error()},
+			},
+		},
+		{
+			name:        "valid form body",
+			method:      http.MethodPost,
+			target:      "/api/pets",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "id=1&name=tom",
+			wantCode:    http.StatusCreated,
+		},
+		{
+			name:        "invalid form body",
+			method:      http.MethodPost,
+			target:      "/api/pets",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "id=abc&name=tom",
+			wantCode:    http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "body", Pointer: "/id", Message: `expected integer, got "abc"`},
+			},
+		},
+		{
+			name:     "missing body",
+			method:   http.MethodPost,
+			target:   "/api/pets",
+			wantCode: http.StatusBadRequest,
+			wantIssues: []ValidationIssue{
+				{In: "body", Message: "request body is required"},
+			},
+		},
+		{
+			name:        "unsupported media type",
+			method:      http.MethodPost,
+			target:      "/api/pets",
+			contentType: "text/plain",
+			body:        "tom",
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "undocumented route",
+			method:   http.MethodGet,
+			target:   "/api/unknown",
+			wantCode: http.StatusNoContent,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantCode >= http.StatusBadRequest {
+				assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+			}
+			if tc.wantIssues == nil {
+				return
+			}
+			var res struct {
+				Errors []ValidationIssue `json:"errors"`
+			}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+			assert.ElementsMatch(t, tc.wantIssues, res.Errors)
+		})
+	}
+}
+
+func TestOpenAPIValidatorBuilder_RejectUnknown(t *testing.T) {
+	e := newContractEngine(t, OpenAPIValidatorBuilder{BasePath: "/api", RejectUnknown: true})
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+}
+
+func TestOpenAPIValidatorBuilder_ValidateResponse(t *testing.T) {
+	e := newContractEngine(t, OpenAPIValidatorBuilder{BasePath: "/api", ValidateResponse: true})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/pets/1", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"id": 1, "name": "tom"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/pets/2", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	var res struct {
+		Detail string            `json:"detail"`
+		Errors []ValidationIssue `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Equal(t, "response does not match the API contract", res.Detail)
+	assert.Equal(t, []ValidationIssue{
+		{In: "response", Pointer: "/name", Message: "property is required"},
+	}, res.Errors)
+}
+
+func TestOpenAPIValidatorBuilder_NilDoc(t *testing.T) {
+	assert.Panics(t, func() {
+		OpenAPIValidatorBuilder{}.Build()
+	})
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File 发送磁盘上的文件
+// 支持单个及多个 Range 请求、Last-Modified/ETag 条件请求和 304 响应，文件内容直接流式写出
+func (c *Context) File(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return c.fileError(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return c.fileError(err)
+	}
+	return c.serveContent(info, f)
+}
+
+// FileFromFS 发送 fs.FS 中的文件，例如 embed.FS
+func (c *Context) FileFromFS(fsys fs.FS, name string) error {
+	f, err := fsys.Open(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return c.fileError(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return c.fileError(err)
+	}
+
+	// 不支持 Seek 的文件需要先读入内存才能处理 Range 请求
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return c.fileError(err)
+		}
+		content = bytes.NewReader(data)
+	}
+	return c.serveContent(info, content)
+}
+
+// Attachment 以附件形式发送文件，浏览器会提示以 filename 保存
+func (c *Context) Attachment(path string, filename string) error {
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	c.Resp.Header().Set("Content-Disposition", contentDisposition("attachment", filename))
+	err := c.File(path)
+	if err != nil {
+		c.Resp.Header().Del("Content-Disposition")
+	}
+	return err
+}
+
+// serveContent 使用 http.ServeContent 处理 Range 和条件请求
+func (c *Context) serveContent(info fs.FileInfo, content io.ReadSeeker) error {
+	if info.IsDir() {
+		return c.fileError(fs.ErrNotExist)
+	}
+	header := c.Resp.Header()
+	if header.Get("ETag") == "" {
+		header.Set("ETag", fileETag(info))
+	}
+
+	rec := &statusRecorder{ResponseWriter: c.Resp, status: http.StatusOK}
+	http.ServeContent(rec, c.Req, info.Name(), info.ModTime(), content)
+	c.StatusCode = rec.status
+	c.written = true
+	return nil
+}
+
+// fileError 将打开文件的错误转换为响应
+func (c *Context) fileError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		c.StatusCode = http.StatusNotFound
+		c.RespData = []byte("404 page not found")
+	case errors.Is(err, fs.ErrPermission):
+		c.StatusCode = http.StatusForbidden
+		c.RespData = []byte("403 Forbidden")
+	default:
+		c.StatusCode = http.StatusInternalServerError
+		c.RespData = []byte("500 Internal Server Error")
+	}
+	return err
+}
+
+// fileETag 根据文件大小和纳秒级的修改时间生成强 ETag
+// http.ServeContent 按强比较处理 If-Range，弱 ETag 会使断点续传总是返回完整内容
+func fileETag(info fs.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
+
+// contentDisposition 生成 Content-Disposition 头
+// filename 使用 ASCII 兜底，filename* 使用 RFC 5987 编码保留原始文件名
+func contentDisposition(dispType string, filename string) string {
+	var fallback strings.Builder
+	needExt := false
+	for _, r := range filename {
+		switch {
+		case r > 0x7e || r < 0x20:
+			fallback.WriteByte('_')
+			needExt = true
+		case r == '"' || r == '\\':
+			fallback.WriteByte('_')
+			needExt = true
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	res := fmt.Sprintf(`%s; filename="%s"`, dispType, fallback.String())
+	if needExt {
+		res += "; filename*=UTF-8''" + rfc5987Escape(filename)
+	}
+	return res
+}
+
+// rfc5987Escape 按 RFC 5987 attr-char 规则对字符串进行百分号编码
+func rfc5987Escape(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if isAttrChar(ch) {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[ch>>4])
+		b.WriteByte(hex[ch&0x0f])
+	}
+	return b.String()
+}
+
+// isAttrChar 判断字节是否为 RFC 5987 中的 attr-char
+func isAttrChar(ch byte) bool {
+	switch {
+	case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", ch) >= 0
+}
+
+// statusRecorder 记录直接写出响应时的状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap 使 http.ResponseController 能访问底层 ResponseWriter
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,205 @@
+package web
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello, go-web!"), 0o644))
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	e := NewEngine()
+	e.GET("/file", func(ctx *Context) {
+		_ = ctx.File(path)
+	})
+	e.GET("/missing", func(ctx *Context) {
+		_ = ctx.File(filepath.Join(dir, "missing.txt"))
+	})
+	e.GET("/download", func(ctx *Context) {
+		_ = ctx.Attachment(path, "报告 2024.txt")
+	})
+
+	testCases := []struct {
+		name       string
+		path       string
+		header     http.Header
+		wantCode   int
+		wantBody   string
+		wantHeader map[string]string
+	}{
+		{
+			name:     "full",
+			path:     "/file",
+			wantCode: http.StatusOK,
+			wantBody: "hello, go-web!",
+			wantHeader: map[string]string{
+				"Content-Type":  "text/plain; charset=utf-8",
+				"Last-Modified": "Mon, 01 Jan 2024 00:00:00 GMT",
+				"Accept-Ranges": "bytes",
+			},
+		},
+		{
+			name:     "single range",
+			path:     "/file",
+			header:   http.Header{"Range": []string{"bytes=7-12"}},
+			wantCode: http.StatusPartialContent,
+			wantBody: "go-web",
+			wantHeader: map[string]string{
+				"Content-Range": "bytes 7-12/14",
+			},
+		},
+		{
+			name:     "unsatisfiable range",
+			path:     "/file",
+			header:   http.Header{"Range": []string{"bytes=100-200"}},
+			wantCode: http.StatusRequestedRangeNotSatisfiable,
+		},
+		{
+			name:     "if modified since",
+			path:     "/file",
+			header:   http.Header{"If-Modified-Since": []string{"Mon, 01 Jan 2024 00:00:00 GMT"}},
+			wantCode: http.StatusNotModified,
+		},
+		{
+			name:     "not found",
+			path:     "/missing",
+			wantCode: http.StatusNotFound,
+			wantBody: "404 page not found",
+		},
+		{
+			name:     "attachment",
+			path:     "/download",
+			wantCode: http.StatusOK,
+			wantBody: "hello, go-web!",
+			wantHeader: map[string]string{
+				"Content-Disposition": `attachment; filename="__ 2024.txt"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%202024.txt`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+			}
+			for k, v := range tc.wantHeader {
+				assert.Equal(t, v, recorder.Header().Get(k))
+			}
+		})
+	}
+
+	// 使用返回的 ETag 发起条件请求
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/file", nil))
+	etag := recorder.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	// 断点续传：If-Range 与 ETag 匹配时返回 206
+	req = httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Range", "bytes=7-12")
+	req.Header.Set("If-Range", etag)
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "bytes 7-12/14", recorder.Header().Get("Content-Range"))
+
+	// 文件已经变化时返回完整内容
+	req.Header.Set("If-Range", `"0-0"`)
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestContext_FileMultiRange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0o644))
+
+	e := NewEngine()
+	var status int
+	e.Use(func(ctx *Context) {
+		ctx.Next()
+		status = ctx.StatusCode
+	})
+	e.GET("/file", func(ctx *Context) {
+		_ = ctx.File(path)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Range", "bytes=0-1,5-6")
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, http.StatusPartialContent, status)
+
+	mediaType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+	reader := multipart.NewReader(recorder.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(part)
+		require.NoError(t, err)
+		parts = append(parts, part.Header.Get("Content-Range")+" "+string(data))
+	}
+	assert.Equal(t, []string{"bytes 0-1/10 01", "bytes 5-6/10 56"}, parts)
+}
+
+func TestContext_FileFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"static/app.js": &fstest.MapFile{Data: []byte("console.log('go-web')"), ModTime: time.Now()},
+	}
+	e := NewEngine()
+	e.GET("/static/*", func(ctx *Context) {
+		_ = ctx.FileFromFS(fsys, ctx.Req.URL.Path)
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/javascript"))
+	assert.Equal(t, "console.log('go-web')", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/static/missing.js", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, `attachment; filename="report.pdf"`, contentDisposition("attachment", "report.pdf"))
+	assert.Equal(t, `attachment; filename="a_b_.txt"; filename*=UTF-8''a%22b%5C.txt`,
+		contentDisposition("attachment", `a"b\.txt`))
+}
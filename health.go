@@ -0,0 +1,250 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 默认的单个检查超时时间
+const defaultCheckTimeout = 5 * time.Second
+
+var ErrNotReady = errors.New("not ready")
+
+// Checker 健康检查
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 函数形式的健康检查，例如 CheckerFunc(db.PingContext)
+type CheckerFunc func(ctx context.Context) error
+
+// Check 执行检查
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckOption 健康检查的可选项
+type CheckOption func(c *healthCheck)
+
+// WithCheckTimeout 设置检查的超时时间，默认为 5 秒
+func WithCheckTimeout(timeout time.Duration) CheckOption {
+	return func(c *healthCheck) {
+		c.timeout = timeout
+	}
+}
+
+// WithCheckCache 缓存检查结果，在 ttl 内直接返回上次的结果，避免频繁探测压垮依赖
+func WithCheckCache(ttl time.Duration) CheckOption {
+	return func(c *healthCheck) {
+		c.ttl = ttl
+	}
+}
+
+// healthCheck 注册的健康检查
+type healthCheck struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time     // 上次检查的时间
+	err       error         // 上次检查的结果
+	duration  time.Duration // 上次检查的耗时
+}
+
+// run 执行检查，缓存未过期时返回缓存的结果
+// 检查不受调用方取消的影响，只受超时时间限制；调用方提前结束时直接返回，结果不会被缓存
+func (c *healthCheck) run(ctx context.Context) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 || c.checkedAt.IsZero() || time.Since(c.checkedAt) >= c.ttl {
+		checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		start := time.Now()
+		done, err := c.check(ctx, checkCtx)
+		cancel()
+		if !done {
+			return CheckResult{Name: c.name, Status: HealthStatusFail, Error: err.Error(), Duration: time.Since(start).String()}
+		}
+		c.err = err
+		c.checkedAt = time.Now()
+		c.duration = c.checkedAt.Sub(start)
+	}
+	res := CheckResult{Name: c.name, Status: HealthStatusOK, Duration: c.duration.String()}
+	if c.err != nil {
+		res.Status = HealthStatusFail
+		res.Error = c.err.Error()
+	}
+	return res
+}
+
+// check 在 checkCtx 上执行检查，超时后不再等待检查返回
+// 调用方的 ctx 先结束时返回 ctx 的错误，done 为 false
+func (c *healthCheck) check(ctx context.Context, checkCtx context.Context) (done bool, err error) {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.checker.Check(checkCtx)
+	}()
+	select {
+	case err = <-errCh:
+		return true, err
+	case <-checkCtx.Done():
+		return true, checkCtx.Err()
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// 健康检查的状态
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// CheckResult 单个检查的结果
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport 健康检查的详细结果
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// HealthOption 健康检查端点的可选项
+type HealthOption func(h *Health)
+
+// WithHealthPrefix 设置健康检查端点的路径前缀，例如 /-/ 对应 /-/livez
+func WithHealthPrefix(prefix string) HealthOption {
+	return func(h *Health) {
+		h.prefix = prefix
+	}
+}
+
+// Health 健康检查端点
+//   - /livez 只执行存活检查，失败时应重启进程
+//   - /readyz 引擎未启动或正在关闭时直接失败，然后执行就绪检查，失败时应暂停分发流量
+//   - /healthz 执行所有检查
+//
+// 检查全部通过时返回 200 和 ok，否则返回 503；带有 verbose 查询参数时返回 JSON 格式的详细结果
+type Health struct {
+	engine    *Engine
+	prefix    string
+	mu        sync.RWMutex
+	liveness  []*healthCheck
+	readiness []*healthCheck
+}
+
+// Health 在引擎上注册 /livez、/readyz 和 /healthz，返回的 Health 用于添加检查
+func (e *Engine) Health(opts ...HealthOption) *Health {
+	h := &Health{engine: e, prefix: "/"}
+	for _, opt := range opts {
+		opt(h)
+	}
+	group := e.Group(h.prefix)
+	group.GET("/livez", h.handler(true, false))
+	group.GET("/readyz", h.handler(false, true))
+	group.GET("/healthz", h.handler(true, true))
+	return h
+}
+
+// AddLivenessCheck 添加存活检查，只应检查进程自身的状态，例如死锁检测
+func (h *Health) AddLivenessCheck(name string, c Checker, opts ...CheckOption) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, newHealthCheck(name, c, opts))
+}
+
+// AddReadinessCheck 添加就绪检查，通常用于检查数据库、缓存等依赖
+func (h *Health) AddReadinessCheck(name string, c Checker, opts ...CheckOption) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, newHealthCheck(name, c, opts))
+}
+
+// newHealthCheck 创建健康检查
+func newHealthCheck(name string, c Checker, opts []CheckOption) *healthCheck {
+	res := &healthCheck{name: name, checker: c, timeout: defaultCheckTimeout}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// Check 并发执行检查并汇总结果，readiness 为 true 时包含引擎的就绪状态
+func (h *Health) Check(ctx context.Context, liveness bool, readiness bool) HealthReport {
+	var checks []*healthCheck
+	h.mu.RLock()
+	if liveness {
+		checks = append(checks, h.liveness...)
+	}
+	if readiness {
+		checks = append(checks, h.readiness...)
+	}
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks), len(checks)+1)
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *healthCheck) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	if readiness {
+		// 关闭时首先变为未就绪，让负载均衡先摘除流量
+		state := CheckResult{Name: "engine", Status: HealthStatusOK, Duration: "0s"}
+		if !h.engine.Ready() {
+			state.Status = HealthStatusFail
+			state.Error = ErrNotReady.Error()
+		}
+		results = append(results, state)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	res := HealthReport{Status: HealthStatusOK, Checks: results}
+	for _, r := range results {
+		if r.Status != HealthStatusOK {
+			res.Status = HealthStatusFail
+			break
+		}
+	}
+	return res
+}
+
+// handler 返回健康检查端点的处理函数
+func (h *Health) handler(liveness bool, readiness bool) HandleFunc {
+	return func(ctx *Context) {
+		// 超时的检查仍在后台运行，不能持有会被复用的 Context
+		report := h.Check(ctx.Req.Context(), liveness, readiness)
+		status := http.StatusOK
+		if report.Status != HealthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.Header("Cache-Control", "no-store")
+		if _, ok := ctx.Req.URL.Query()["verbose"]; ok {
+			data, err := json.Marshal(report)
+			if err != nil {
+				ctx.AbortWithError(err)
+				return
+			}
+			_ = ctx.Data(status, "application/json", data)
+			return
+		}
+		_ = ctx.String(status, report.Status)
+	}
+}
@@ -0,0 +1,237 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	e := NewEngine()
+	h := e.Health()
+	var dbErr atomic.Value
+	dbErr.Store(errors.New(""))
+	h.AddLivenessCheck("goroutines", CheckerFunc(func(ctx context.Context) error {
+		return nil
+	}))
+	h.AddReadinessCheck("db", CheckerFunc(func(ctx context.Context) error {
+		if err := dbErr.Load().(error); err.Error() != "" {
+			return err
+		}
+		return nil
+	}))
+
+	testCases := []struct {
+		name       string
+		ready      bool
+		dbErr      error
+		path       string
+		wantCode   int
+		wantBody   string
+		wantChecks map[string]string
+	}{
+		{
+			name:     "live",
+			path:     "/livez",
+			wantCode: http.StatusOK,
+			wantBody: "ok",
+		},
+		{
+			// 存活检查不受就绪状态和依赖的影响
+			name:       "live verbose with db down",
+			dbErr:      errors.New("connection refused"),
+			path:       "/livez?verbose",
+			wantCode:   http.StatusOK,
+			wantChecks: map[string]string{"goroutines": HealthStatusOK},
+		},
+		{
+			name:     "ready",
+			ready:    true,
+			path:     "/readyz",
+			wantCode: http.StatusOK,
+			wantBody: "ok",
+		},
+		{
+			name:       "not started",
+			path:       "/readyz?verbose",
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"db": HealthStatusOK, "engine": HealthStatusFail},
+		},
+		{
+			name:     "db down",
+			ready:    true,
+			dbErr:    errors.New("connection refused"),
+			path:     "/readyz",
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: "fail",
+		},
+		{
+			name:       "healthz verbose",
+			ready:      true,
+			dbErr:      errors.New("connection refused"),
+			path:       "/healthz?verbose",
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"goroutines": HealthStatusOK, "db": HealthStatusFail, "engine": HealthStatusOK},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e.lifecycle.ready.Store(tc.ready)
+			if tc.dbErr != nil {
+				dbErr.Store(tc.dbErr)
+			} else {
+				dbErr.Store(errors.New(""))
+			}
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+			if tc.wantChecks == nil {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+				return
+			}
+			var report HealthReport
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+			checks := make(map[string]string, len(report.Checks))
+			for _, c := range report.Checks {
+				checks[c.Name] = c.Status
+				if c.Name == "db" && c.Status == HealthStatusFail {
+					assert.Equal(t, "connection refused", c.Error)
+				}
+			}
+			assert.Equal(t, tc.wantChecks, checks)
+		})
+	}
+}
+
+func TestHealth_TimeoutAndCache(t *testing.T) {
+	e := NewEngine()
+	h := e.Health(WithHealthPrefix("/-"))
+	var calls atomic.Int32
+	h.AddLivenessCheck("cached", CheckerFunc(func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}), WithCheckCache(time.Minute))
+	h.AddLivenessCheck("slow", CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}), WithCheckTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	report := h.Check(context.Background(), true, false)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, HealthStatusFail, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, HealthStatusOK, report.Checks[0].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[1].Error)
+
+	h.Check(context.Background(), true, false)
+	assert.Equal(t, int32(1), calls.Load())
+
+	req := httptest.NewRequest(http.MethodGet, "/-/livez", nil)
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestHealth_Drain(t *testing.T) {
+	e := NewEngine(WithDrainDelay(200 * time.Millisecond))
+	e.Health()
+	go func() {
+		_ = e.Start("127.0.0.1:0")
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	url := "http://" + e.Addr().String()
+	assert.Equal(t, "ok", getBody(t, http.DefaultClient, url+"/readyz"))
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- e.Shutdown(context.Background())
+	}()
+	// 关闭期间仍在接收请求，但就绪检查已经失败
+	require.Eventually(t, func() bool {
+		return !e.Ready()
+	}, time.Second, time.Millisecond)
+	resp, err := http.Get(url + "/readyz")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "ok", getBody(t, http.DefaultClient, url+"/livez"))
+	assert.NoError(t, <-shutdown)
+}
+
+func TestHealth_CallerCanceled(t *testing.T) {
+	e := NewEngine()
+	h := e.Health()
+	release := make(chan struct{})
+	var calls atomic.Int32
+	h.AddLivenessCheck("db", CheckerFunc(func(ctx context.Context) error {
+		if calls.Add(1) == 1 {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}), WithCheckCache(time.Minute))
+
+	// 探测方在检查过程中断开
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/livez", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	time.AfterFunc(50*time.Millisecond, cancel)
+	e.ServeHTTP(recorder, req)
+	close(release)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	// 调用方的取消不会被缓存
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, int32(2), calls.Load())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHealth_TimedOutCheckerContext(t *testing.T) {
+	e := NewEngine()
+	release := make(chan struct{})
+	seen := make(chan any, 1)
+	var user any
+	e.GET("/me", func(ctx *Context) {
+		ctx.Set("user", "bob")
+		// 在其他请求处理期间让超时的检查继续执行
+		close(release)
+		user = <-seen
+		_ = ctx.String(http.StatusOK, "ok")
+	})
+	h := e.Health()
+	h.AddLivenessCheck("slow", CheckerFunc(func(ctx context.Context) error {
+		<-release
+		seen <- ctx.Value("user")
+		return nil
+	}), WithCheckTimeout(20*time.Millisecond))
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	// 检查超时后 Context 被其他请求复用，后台的检查不能看到其他请求的值
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/me", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Nil(t, user)
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// HTTP2Config HTTP/2 配置，零值表示使用默认值
+type HTTP2Config struct {
+	MaxConcurrentStreams         uint32        // 每个连接允许同时处理的流数量，默认 250
+	MaxReadFrameSize             uint32        // 允许读取的最大帧大小，范围为 16KB ~ 16MB
+	MaxUploadBufferPerConnection int32         // 每个连接的初始流量控制窗口大小
+	MaxUploadBufferPerStream     int32         // 每个流的初始流量控制窗口大小
+	IdleTimeout                  time.Duration // 空闲连接的超时时间，默认使用 http.Server 的 IdleTimeout
+	ReadIdleTimeout              time.Duration // 连接超过该时间没有收到帧时发送 PING 进行健康检查
+	PingTimeout                  time.Duration // 健康检查 PING 的超时时间
+}
+
+// WithHTTP2 设置 HTTP/2 参数，对 HTTPS 和 h2c 都生效
+func WithHTTP2(cfg HTTP2Config) EngineOption {
+	return func(e *Engine) {
+		e.lifecycle.http2 = &cfg
+	}
+}
+
+// WithH2C 在明文监听上同时支持 HTTP/1.1 和 HTTP/2 (h2c)
+// 支持通过 prior knowledge 直接发起的 HTTP/2 连接，以及通过 Upgrade: h2c 升级的连接
+func WithH2C() EngineOption {
+	return func(e *Engine) {
+		e.lifecycle.h2c = true
+	}
+}
+
+// configureHTTP2 根据 HTTP/2 配置设置 http.Server，只会执行一次
+func (e *Engine) configureHTTP2(srv *http.Server) error {
+	lc := e.lifecycle
+	lc.http2Once.Do(func() {
+		if lc.http2 == nil && !lc.h2c {
+			return
+		}
+		cfg := lc.http2
+		if cfg == nil {
+			cfg = &HTTP2Config{}
+		}
+		h2s := &http2.Server{
+			MaxConcurrentStreams:         cfg.MaxConcurrentStreams,
+			MaxReadFrameSize:             cfg.MaxReadFrameSize,
+			MaxUploadBufferPerConnection: cfg.MaxUploadBufferPerConnection,
+			MaxUploadBufferPerStream:     cfg.MaxUploadBufferPerStream,
+			IdleTimeout:                  cfg.IdleTimeout,
+			ReadIdleTimeout:              cfg.ReadIdleTimeout,
+			PingTimeout:                  cfg.PingTimeout,
+		}
+		// 同时注册关闭钩子，Shutdown 时向 HTTP/2 连接发送 GOAWAY
+		if lc.http2Err = http2.ConfigureServer(srv, h2s); lc.http2Err != nil {
+			return
+		}
+		if lc.h2c {
+			srv.Handler = h2c.NewHandler(srv.Handler, h2s)
+		}
+	})
+	return lc.http2Err
+}
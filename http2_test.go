@@ -0,0 +1,133 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestEngine_H2C(t *testing.T) {
+	const n = 5
+	var conns atomic.Int32
+	var middlewareCalls atomic.Int32
+	arrived := make(chan struct{}, n)
+	release := make(chan struct{})
+
+	e := NewEngine(WithH2C(), WithConnState(func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}))
+	e.Use(func(ctx *Context) {
+		middlewareCalls.Add(1)
+		ctx.Next()
+	})
+	e.GET("/user/:id", func(ctx *Context) {
+		if ctx.Req.ProtoMajor == 2 {
+			// 所有请求同时到达后再返回，证明请求在同一个连接上并发处理
+			arrived <- struct{}{}
+			<-release
+		}
+		_ = ctx.String(http.StatusOK, ctx.Req.Proto+" "+ctx.PathParams["id"])
+	})
+	go func() {
+		_ = e.Start("127.0.0.1:0")
+	}()
+	defer func() {
+		_ = e.Shutdown(context.Background())
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	base := "http://" + e.Addr().String()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := client.Get(base + "/user/" + string(rune('a'+i)))
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			data, _ := io.ReadAll(resp.Body)
+			bodies[i] = string(data)
+		}(i)
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case <-arrived:
+		case <-time.After(2 * time.Second):
+			t.Fatal("requests were not multiplexed")
+		}
+	}
+	close(release)
+	wg.Wait()
+	for i, body := range bodies {
+		assert.Equal(t, "HTTP/2.0 "+string(rune('a'+i)), body)
+	}
+	assert.Equal(t, int32(1), conns.Load())
+	assert.Equal(t, int32(n), middlewareCalls.Load())
+
+	// 同一个监听上仍然支持 HTTP/1.1
+	resp, err := http.Get(base + "/user/x")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 x", string(data))
+}
+
+func TestEngine_HTTP2Settings(t *testing.T) {
+	e := NewEngine(WithH2C(), WithHTTP2(HTTP2Config{
+		MaxConcurrentStreams: 10,
+		MaxReadFrameSize:     1 << 20,
+	}))
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "ok")
+	})
+	go func() {
+		_ = e.Start("127.0.0.1:0")
+	}()
+	defer func() {
+		_ = e.Shutdown(context.Background())
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	conn, err := net.Dial("tcp", e.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(2*time.Second)))
+	_, err = conn.Write([]byte(http2.ClientPreface))
+	require.NoError(t, err)
+	framer := http2.NewFramer(conn, conn)
+	require.NoError(t, framer.WriteSettings())
+
+	frame, err := framer.ReadFrame()
+	require.NoError(t, err)
+	settings, ok := frame.(*http2.SettingsFrame)
+	require.True(t, ok)
+	streams, ok := settings.Value(http2.SettingMaxConcurrentStreams)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(10), streams)
+	frameSize, ok := settings.Value(http2.SettingMaxFrameSize)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1<<20), frameSize)
+}
@@ -0,0 +1,103 @@
+package web
+
+import (
+	"errors"
+	"sync"
+)
+
+// Hub 管理 websocket 连接的房间，用于按房间广播消息
+type Hub struct {
+	mu    sync.RWMutex
+	rooms map[string]map[*WebSocketConn]struct{} // room -> 连接集合
+}
+
+// NewHub 创建一个新的 Hub
+func NewHub() *Hub {
+	return &Hub{
+		rooms: make(map[string]map[*WebSocketConn]struct{}),
+	}
+}
+
+// Join 将连接加入房间
+func (h *Hub) Join(room string, conn *WebSocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	members, ok := h.rooms[room]
+	if !ok {
+		members = make(map[*WebSocketConn]struct{})
+		h.rooms[room] = members
+	}
+	members[conn] = struct{}{}
+}
+
+// Leave 将连接移出房间，房间为空时删除房间
+func (h *Hub) Leave(room string, conn *WebSocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(room, conn)
+}
+
+// LeaveAll 将连接移出所有房间，通常在连接断开时调用
+func (h *Hub) LeaveAll(conn *WebSocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for room := range h.rooms {
+		h.leave(room, conn)
+	}
+}
+
+func (h *Hub) leave(room string, conn *WebSocketConn) {
+	members, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+	delete(members, conn)
+	if len(members) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Rooms 返回当前所有房间名
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	res := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		res = append(res, room)
+	}
+	return res
+}
+
+// Count 返回房间内的连接数
+func (h *Hub) Count(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Broadcast 向房间内所有连接发送消息
+// 发送失败的连接会被移出所有房间，返回所有发送失败的错误
+func (h *Hub) Broadcast(room string, mt MessageType, data []byte) error {
+	return h.BroadcastExcept(room, nil, mt, data)
+}
+
+// BroadcastExcept 向房间内除 except 以外的连接发送消息
+func (h *Hub) BroadcastExcept(room string, except *WebSocketConn, mt MessageType, data []byte) error {
+	h.mu.RLock()
+	members := make([]*WebSocketConn, 0, len(h.rooms[room]))
+	for conn := range h.rooms[room] {
+		if conn != except {
+			members = append(members, conn)
+		}
+	}
+	h.mu.RUnlock()
+
+	var errs []error
+	for _, conn := range members {
+		if err := conn.WriteMessage(mt, data); err != nil {
+			errs = append(errs, err)
+			h.LeaveAll(conn)
+		}
+	}
+	return errors.Join(errs...)
+}
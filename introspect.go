@@ -0,0 +1,79 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// 内省相关的环境变量，由 goweb 命令在运行应用时设置
+const (
+	IntrospectEnv       = "GOWEB_INTROSPECT"        // routes 输出路由表，openapi 输出 OpenAPI 文档
+	IntrospectOutputEnv = "GOWEB_INTROSPECT_OUTPUT" // 输出文件，为空时输出到标准输出
+)
+
+// 内省的输出内容
+const (
+	IntrospectRoutes  = "routes"
+	IntrospectOpenAPI = "openapi"
+)
+
+// ErrIntrospected 已经输出了内省结果，Start、Run 等方法返回该错误而不监听任何端口，
+// 调用方应将其视为正常退出
+var ErrIntrospected = errors.New("web: introspection done")
+
+// introspection 内省配置
+type introspection struct {
+	openAPIOptions []OpenAPIOption // 生成 OpenAPI 文档的选项
+}
+
+// WithIntrospection 开启内省，供 goweb routes 和 goweb openapi 命令读取应用的路由
+// 设置了 GOWEB_INTROSPECT 环境变量时，Start、Run 等方法在监听端口之前以 JSON 输出路由表或 OpenAPI 文档，
+// 然后返回 ErrIntrospected，不处理任何请求；未设置时不影响启动。opts 用于生成 OpenAPI 文档
+func WithIntrospection(opts ...OpenAPIOption) EngineOption {
+	return func(e *Engine) {
+		e.introspection = &introspection{openAPIOptions: opts}
+	}
+}
+
+// introspectIfRequested 开启了内省且设置了 GOWEB_INTROSPECT 时输出内省结果并返回 ErrIntrospected，
+// 否则返回 nil
+func (e *Engine) introspectIfRequested() error {
+	if e.introspection == nil {
+		return nil
+	}
+	mode := os.Getenv(IntrospectEnv)
+	if mode == "" {
+		return nil
+	}
+	if err := e.introspect(mode, os.Getenv(IntrospectOutputEnv), e.introspection.openAPIOptions); err != nil {
+		return err
+	}
+	return ErrIntrospected
+}
+
+// introspect 将内省结果写入 output，output 为空时写入标准输出
+func (e *Engine) introspect(mode string, output string, opts []OpenAPIOption) error {
+	var val any
+	switch mode {
+	case IntrospectRoutes:
+		val = e.Routes()
+	case IntrospectOpenAPI:
+		val = e.OpenAPI(opts...)
+	default:
+		return fmt.Errorf("web: unknown %s %q", IntrospectEnv, mode)
+	}
+	if output == "" {
+		return json.NewEncoder(os.Stdout).Encode(val)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(f).Encode(val); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
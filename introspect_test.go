@@ -0,0 +1,69 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Andras5014/go-web/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIntrospection(t *testing.T) {
+	// 端口已经被占用，内省时不应该监听
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	addr := l.Addr().String()
+
+	newEngine := func() *Engine {
+		e := NewEngine(WithIntrospection(WithOpenAPIInfo(openapi.Info{Title: "demo", Version: "1.0"})))
+		e.GET("/users/:id", func(ctx *Context) {})
+		e.NameRoute("user", "/users/:id")
+		return e
+	}
+
+	t.Run("routes", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "routes.json")
+		t.Setenv(IntrospectEnv, IntrospectRoutes)
+		t.Setenv(IntrospectOutputEnv, output)
+		assert.ErrorIs(t, newEngine().Start(addr), ErrIntrospected)
+
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		var routes []RouteInfo
+		require.NoError(t, json.Unmarshal(data, &routes))
+		require.Len(t, routes, 1)
+		assert.Equal(t, "GET", routes[0].Method)
+		assert.Equal(t, "/users/:id", routes[0].Path)
+		assert.Equal(t, "user", routes[0].Name)
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "openapi.json")
+		t.Setenv(IntrospectEnv, IntrospectOpenAPI)
+		t.Setenv(IntrospectOutputEnv, output)
+		assert.ErrorIs(t, newEngine().Run(context.Background(), addr), ErrIntrospected)
+
+		doc, err := openapi.Load(output)
+		require.NoError(t, err)
+		assert.Equal(t, "demo", doc.Info.Title)
+		assert.Contains(t, doc.Paths, "/users/{id}")
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		t.Setenv(IntrospectEnv, "unknown")
+		assert.EqualError(t, newEngine().Start(addr), `web: unknown GOWEB_INTROSPECT "unknown"`)
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		t.Setenv(IntrospectEnv, IntrospectRoutes)
+		err := NewEngine().Start(addr)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrIntrospected)
+	})
+}
@@ -0,0 +1,355 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout Run 收到退出信号后等待请求处理完成的默认时间
+const defaultShutdownTimeout = 30 * time.Second
+
+// Hook 生命周期钩子
+type Hook func(ctx context.Context) error
+
+// lifecycle 引擎的生命周期状态
+type lifecycle struct {
+	mu             sync.Mutex
+	server         *http.Server   // 托管的 http.Server
+	listeners      []net.Listener // 正在使用的监听，重启时传递给新进程
+	addrs          []net.Addr     // 正在监听的地址
+	beforeStart    []Hook
+	afterStart     []Hook
+	beforeShutdown []Hook
+	afterShutdown  []Hook
+
+	beforeStartOnce sync.Once // 保证启动钩子只执行一次
+	beforeStartErr  error
+	afterStartOnce  sync.Once
+	afterStartErr   error
+
+	ready        atomic.Bool   // 是否可以接收流量
+	shuttingDown bool          // 是否已经开始关闭，由 mu 保护，开始关闭后不再变为就绪
+	shutdownOnce sync.Once     // 保证只关闭一次
+	shutdownDone chan struct{} // 关闭完成后关闭该 channel
+	shutdownErr  error         // 关闭过程中的错误
+
+	signals         []os.Signal   // 触发优雅关闭的信号
+	shutdownTimeout time.Duration // 优雅关闭的超时时间
+	drainDelay      time.Duration // 标记为未就绪后等待的时间，留给负载均衡摘除流量
+	restartSignals  []os.Signal   // 触发平滑重启的信号
+	restartCmd      []string      // 重启时执行的命令，默认为当前程序及其参数
+
+	connState   func(net.Conn, http.ConnState) // 用户设置的连接状态回调
+	activeConns atomic.Int64                   // 当前的连接数
+
+	http2     *HTTP2Config // HTTP/2 配置
+	h2c       bool         // 是否在明文监听上支持 HTTP/2
+	http2Once sync.Once    // 保证只配置一次 HTTP/2
+	http2Err  error        // 配置 HTTP/2 的错误
+}
+
+// newLifecycle 创建默认的生命周期状态
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		shutdownDone:    make(chan struct{}),
+		signals:         []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+}
+
+// WithShutdownSignals 设置 Run 时触发优雅关闭的信号，默认为 SIGINT 和 SIGTERM
+func WithShutdownSignals(signals ...os.Signal) EngineOption {
+	return func(e *Engine) {
+		e.lifecycle.signals = signals
+	}
+}
+
+// WithShutdownTimeout 设置 Run 优雅关闭时等待请求处理完成的最长时间
+func WithShutdownTimeout(timeout time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.lifecycle.shutdownTimeout = timeout
+	}
+}
+
+// WithDrainDelay 设置关闭时标记为未就绪后、停止接收连接前的等待时间
+func WithDrainDelay(delay time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.lifecycle.drainDelay = delay
+	}
+}
+
+// OnBeforeStart 注册启动前的钩子，按注册顺序执行，任意钩子失败都会终止启动
+func (e *Engine) OnBeforeStart(h Hook) {
+	e.lifecycle.mu.Lock()
+	defer e.lifecycle.mu.Unlock()
+	e.lifecycle.beforeStart = append(e.lifecycle.beforeStart, h)
+}
+
+// OnAfterStart 注册开始监听后的钩子，按注册顺序执行
+func (e *Engine) OnAfterStart(h Hook) {
+	e.lifecycle.mu.Lock()
+	defer e.lifecycle.mu.Unlock()
+	e.lifecycle.afterStart = append(e.lifecycle.afterStart, h)
+}
+
+// OnBeforeShutdown 注册停止接收连接前的钩子，按注册顺序的逆序执行
+func (e *Engine) OnBeforeShutdown(h Hook) {
+	e.lifecycle.mu.Lock()
+	defer e.lifecycle.mu.Unlock()
+	e.lifecycle.beforeShutdown = append(e.lifecycle.beforeShutdown, h)
+}
+
+// OnAfterShutdown 注册所有请求处理完成后的钩子，按注册顺序的逆序执行，适合释放资源
+func (e *Engine) OnAfterShutdown(h Hook) {
+	e.lifecycle.mu.Lock()
+	defer e.lifecycle.mu.Unlock()
+	e.lifecycle.afterShutdown = append(e.lifecycle.afterShutdown, h)
+}
+
+// Ready 返回引擎是否可以接收流量，启动完成后为 true，开始关闭时立即变为 false
+func (e *Engine) Ready() bool {
+	return e.lifecycle.ready.Load()
+}
+
+// Addr 返回正在监听的第一个地址，未启动时返回 nil
+func (e *Engine) Addr() net.Addr {
+	e.lifecycle.mu.Lock()
+	defer e.lifecycle.mu.Unlock()
+	if len(e.lifecycle.addrs) == 0 {
+		return nil
+	}
+	return e.lifecycle.addrs[0]
+}
+
+// Server 返回引擎托管的 http.Server，可以在启动前修改其配置
+func (e *Engine) Server() *http.Server {
+	e.lifecycle.mu.Lock()
+	defer e.lifecycle.mu.Unlock()
+	if e.lifecycle.server == nil {
+		e.lifecycle.server = &http.Server{
+			Handler:           e,
+			ReadHeaderTimeout: defaultReadHeaderTimeout,
+			ConnState:         e.lifecycle.trackConn,
+		}
+	}
+	return e.lifecycle.server
+}
+
+// Run 在 addr 上启动服务器，直到 ctx 结束、收到退出信号或调用 Shutdown，然后优雅关闭
+// 优雅关闭完成后返回，正常关闭时返回 nil
+func (e *Engine) Run(ctx context.Context, addr string) error {
+	if err := e.introspectIfRequested(); err != nil {
+		return err
+	}
+	l, err := listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return e.runListeners(ctx, []net.Listener{l}, false)
+}
+
+// RunListeners 同时在多个监听上提供服务，直到 ctx 结束、收到退出信号、调用 Shutdown
+// 或任意一个监听出错，然后优雅关闭
+func (e *Engine) RunListeners(ctx context.Context, listeners ...net.Listener) error {
+	if len(listeners) == 0 {
+		return errors.New("web: no listener")
+	}
+	if err := e.introspectIfRequested(); err != nil {
+		for _, l := range listeners {
+			_ = l.Close()
+		}
+		return err
+	}
+	return e.runListeners(ctx, listeners, false)
+}
+
+// runListeners RunListeners 的实现，调用方已经检查过内省，useTLS 为 true 时提供 HTTPS 服务
+func (e *Engine) runListeners(ctx context.Context, listeners []net.Listener, useTLS bool) error {
+	if len(e.lifecycle.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, e.lifecycle.signals...)
+		defer stop()
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			errCh <- e.serveListener(l, useTLS)
+		}(l)
+	}
+
+	var restartCh chan os.Signal
+	if len(e.lifecycle.restartSignals) > 0 {
+		restartCh = make(chan os.Signal, 1)
+		signal.Notify(restartCh, e.lifecycle.restartSignals...)
+		defer signal.Stop(restartCh)
+	}
+
+	var errs []error
+	remaining := len(listeners)
+	for done := false; !done; {
+		select {
+		case err := <-errCh:
+			remaining--
+			done = true
+			if err == nil {
+				// 其他地方调用了 Shutdown 或已经重启，等待关闭完成
+				<-e.lifecycle.shutdownDone
+				errs = append(errs, e.lifecycle.shutdownErr)
+				break
+			}
+			errs = append(errs, err)
+			// 某个监听出错，关闭其他监听
+			errs = append(errs, e.shutdownWithTimeout())
+		case <-ctx.Done():
+			done = true
+			errs = append(errs, e.shutdownWithTimeout())
+		case <-restartCh:
+			// 重启成功后当前引擎已经关闭，会在下一轮从 errCh 返回
+			if err := e.restartWithTimeout(ctx); err != nil {
+				log.Println("web: restart failed:", err)
+			}
+		}
+	}
+	for ; remaining > 0; remaining-- {
+		errs = append(errs, <-errCh)
+	}
+	return errors.Join(errs...)
+}
+
+// shutdownWithTimeout 使用 WithShutdownTimeout 设置的超时时间优雅关闭
+func (e *Engine) shutdownWithTimeout() error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.lifecycle.shutdownTimeout)
+	defer cancel()
+	return e.Shutdown(ctx)
+}
+
+// serveListener 执行启动钩子后在 l 上提供服务，useTLS 为 true 时使用 Server().TLSConfig 提供 HTTPS 服务
+// 在多个监听上提供服务时，启动钩子只执行一次；优雅关闭时返回 nil
+func (e *Engine) serveListener(l net.Listener, useTLS bool) error {
+	lc := e.lifecycle
+	startCtx := context.Background()
+	lc.beforeStartOnce.Do(func() {
+		for _, h := range lc.hooks(&lc.beforeStart) {
+			if lc.beforeStartErr = h(startCtx); lc.beforeStartErr != nil {
+				return
+			}
+		}
+	})
+	if lc.beforeStartErr != nil {
+		_ = l.Close()
+		return lc.beforeStartErr
+	}
+
+	srv := e.Server()
+	if err := e.configureHTTP2(srv); err != nil {
+		_ = l.Close()
+		return err
+	}
+	lc.mu.Lock()
+	lc.listeners = append(lc.listeners, l)
+	lc.addrs = append(lc.addrs, l.Addr())
+	lc.mu.Unlock()
+
+	// 这里可以执行after start的操作
+	if e.AfterStart != nil {
+		e.AfterStart(l)
+	}
+	lc.afterStartOnce.Do(func() {
+		for _, h := range lc.hooks(&lc.afterStart) {
+			if lc.afterStartErr = h(startCtx); lc.afterStartErr != nil {
+				return
+			}
+		}
+	})
+	if lc.afterStartErr != nil {
+		_ = l.Close()
+		return lc.afterStartErr
+	}
+	lc.mu.Lock()
+	// 关闭可能在启动过程中开始，此时不能再变为就绪
+	ready := !lc.shuttingDown
+	if ready {
+		lc.ready.Store(true)
+	}
+	lc.mu.Unlock()
+	if ready {
+		notifyParentReady()
+	}
+
+	var err error
+	if useTLS {
+		// 证书由 TLSConfig 提供，ServeTLS 会同时开启 HTTP/2
+		err = srv.ServeTLS(l, "", "")
+	} else {
+		err = srv.Serve(l)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// hooks 返回钩子列表的副本
+func (l *lifecycle) hooks(list *[]Hook) []Hook {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Hook(nil), *list...)
+}
+
+// Shutdown 优雅关闭引擎：
+// 先将就绪状态置为 false 并等待 drain delay，然后执行 BeforeShutdown 钩子，
+// 停止接收新连接并等待进行中的请求处理完成，最后执行 AfterShutdown 钩子
+// 多次调用只会关闭一次，后续调用等待第一次关闭完成并返回相同的结果
+func (e *Engine) Shutdown(ctx context.Context) error {
+	lc := e.lifecycle
+	lc.shutdownOnce.Do(func() {
+		defer close(lc.shutdownDone)
+		lc.mu.Lock()
+		lc.shuttingDown = true
+		lc.ready.Store(false)
+		lc.mu.Unlock()
+
+		if lc.drainDelay > 0 {
+			select {
+			case <-time.After(lc.drainDelay):
+			case <-ctx.Done():
+			}
+		}
+
+		var errs []error
+		before := lc.hooks(&lc.beforeShutdown)
+		for i := len(before) - 1; i >= 0; i-- {
+			errs = append(errs, before[i](ctx))
+		}
+
+		lc.mu.Lock()
+		srv := lc.server
+		lc.mu.Unlock()
+		if srv != nil {
+			errs = append(errs, srv.Shutdown(ctx))
+		}
+
+		after := lc.hooks(&lc.afterShutdown)
+		for i := len(after) - 1; i >= 0; i-- {
+			errs = append(errs, after[i](ctx))
+		}
+		lc.shutdownErr = errors.Join(errs...)
+	})
+
+	select {
+	case <-lc.shutdownDone:
+		return lc.shutdownErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hookRecorder 记录钩子的执行顺序
+type hookRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (h *hookRecorder) hook(name string) Hook {
+	return func(ctx context.Context) error {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.calls = append(h.calls, name)
+		return nil
+	}
+}
+
+func (h *hookRecorder) get() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.calls...)
+}
+
+func TestEngine_Run(t *testing.T) {
+	e := NewEngine(WithShutdownTimeout(5 * time.Second))
+	started := make(chan struct{})
+	e.GET("/slow", func(ctx *Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		_ = ctx.String(http.StatusOK, "done")
+	})
+
+	recorder := &hookRecorder{}
+	e.OnBeforeStart(recorder.hook("before start 1"))
+	e.OnBeforeStart(recorder.hook("before start 2"))
+	e.OnAfterStart(recorder.hook("after start"))
+	e.OnBeforeShutdown(recorder.hook("before shutdown 1"))
+	e.OnBeforeShutdown(func(ctx context.Context) error {
+		// 关闭钩子执行时已经不再就绪
+		assert.False(t, e.Ready())
+		return recorder.hook("before shutdown 2")(ctx)
+	})
+	e.OnAfterShutdown(recorder.hook("after shutdown 1"))
+	e.OnAfterShutdown(recorder.hook("after shutdown 2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- e.Run(ctx, "127.0.0.1:0")
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + e.Addr().String() + "/slow")
+		if err != nil {
+			respCh <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		respCh <- string(body)
+	}()
+	<-started
+	cancel()
+
+	// 进行中的请求处理完成后才退出
+	assert.Equal(t, "done", <-respCh)
+	require.NoError(t, <-runErr)
+	assert.False(t, e.Ready())
+	assert.Equal(t, []string{
+		"before start 1", "before start 2", "after start",
+		"before shutdown 2", "before shutdown 1",
+		"after shutdown 2", "after shutdown 1",
+	}, recorder.get())
+}
+
+func TestEngine_StartShutdown(t *testing.T) {
+	e := NewEngine()
+	e.GET("/", func(ctx *Context) {
+		ctx.Status(http.StatusOK)
+	})
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- e.Start("127.0.0.1:0")
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	resp, err := http.Get("http://" + e.Addr().String() + "/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	require.NoError(t, <-startErr)
+	// 重复关闭返回相同的结果
+	require.NoError(t, e.Shutdown(context.Background()))
+
+	_, err = http.Get("http://" + e.Addr().String() + "/")
+	assert.Error(t, err)
+}
+
+func TestEngine_BeforeStartError(t *testing.T) {
+	e := NewEngine()
+	hookErr := errors.New("db unavailable")
+	e.OnBeforeStart(func(ctx context.Context) error {
+		return hookErr
+	})
+	err := e.Run(context.Background(), "127.0.0.1:0")
+	assert.ErrorIs(t, err, hookErr)
+	assert.False(t, e.Ready())
+}
+
+func TestEngine_ShutdownDuringStart(t *testing.T) {
+	e := NewEngine()
+	// 启动钩子执行期间开始关闭，启动完成后不能再变为就绪
+	e.OnAfterStart(func(ctx context.Context) error {
+		return e.Shutdown(ctx)
+	})
+	require.NoError(t, e.Start("127.0.0.1:0"))
+	assert.False(t, e.Ready())
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package web
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_RunSignal(t *testing.T) {
+	e := NewEngine(WithShutdownSignals(syscall.SIGUSR1))
+	shutdown := make(chan struct{})
+	e.OnAfterShutdown(func(ctx context.Context) error {
+		close(shutdown)
+		return nil
+	})
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- e.Run(context.Background(), "127.0.0.1:0")
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	require.NoError(t, <-runErr)
+	<-shutdown
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// systemd socket activation 传递的第一个文件描述符
+const listenFDsStart = 3
+
+// Serve 在 l 上提供服务，直到调用 Shutdown
+// 可以在多个 goroutine 中对不同的监听调用，所有监听共享同一个 http.Server 和路由
+// 需要不同路由的多个端口(例如业务端口和管理端口)应使用不同的 Engine
+func (e *Engine) Serve(l net.Listener) error {
+	if err := e.introspectIfRequested(); err != nil {
+		_ = l.Close()
+		return err
+	}
+	return e.serveListener(l, false)
+}
+
+// StartUnix 在 Unix domain socket 上启动服务器，perm 为 socket 文件的权限
+func (e *Engine) StartUnix(path string, perm os.FileMode) error {
+	if err := e.introspectIfRequested(); err != nil {
+		return err
+	}
+	l := takeInherited("unix", path)
+	if l == nil {
+		var err error
+		if l, err = ListenUnix(path, perm); err != nil {
+			return err
+		}
+	}
+	return e.serveListener(l, false)
+}
+
+// ListenUnix 监听 Unix domain socket 并设置 socket 文件的权限
+// 会先删除上次未正常退出遗留的 socket 文件，path 存在但不是 socket 时返回错误
+// 监听关闭时会删除 socket 文件
+func ListenUnix(path string, perm os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("web: %s exists and is not a socket", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(path, perm); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// SystemdListener systemd socket activation 传递的监听
+type SystemdListener struct {
+	net.Listener
+	Name string // socket 单元中 FileDescriptorName 的值，默认为 socket 单元的名称
+}
+
+// SystemdListeners 返回 systemd socket activation 传递的监听，顺序与 systemd 传递的顺序一致
+// 不是由 systemd 激活时返回空列表；读取后会清除 LISTEN_* 环境变量，避免被子进程继承
+func SystemdListeners() ([]SystemdListener, error) {
+	pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+	if pid == "" || fds == "" {
+		return nil, nil
+	}
+	if pid != strconv.Itoa(os.Getpid()) {
+		// 传递给父进程的文件描述符
+		return nil, nil
+	}
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("web: invalid LISTEN_FDS %q", fds)
+	}
+	return listenersFromFDs(listenFDsStart, n, names)
+}
+
+// listenersFromFDs 将从 start 开始的 n 个文件描述符转换为监听
+func listenersFromFDs(start int, n int, names string) ([]SystemdListener, error) {
+	var nameList []string
+	if names != "" {
+		nameList = strings.Split(names, ":")
+	}
+	res := make([]SystemdListener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "unknown"
+		if i < len(nameList) {
+			name = nameList[i]
+		}
+		f := os.NewFile(uintptr(start+i), name)
+		// FileListener 会复制文件描述符，原文件描述符可以关闭
+		l, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, prev := range res {
+				_ = prev.Close()
+			}
+			return nil, fmt.Errorf("web: fd %d (%s): %w", start+i, name, err)
+		}
+		res = append(res, SystemdListener{Listener: l, Name: name})
+	}
+	return res, nil
+}
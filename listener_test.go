@@ -0,0 +1,154 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getBody 发送 GET 请求并返回响应体
+func getBody(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestEngine_Serve(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	e := NewEngine()
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "ok")
+	})
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- e.Serve(l)
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	assert.Equal(t, l.Addr(), e.Addr())
+	assert.Equal(t, "ok", getBody(t, http.DefaultClient, "http://"+l.Addr().String()+"/"))
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	assert.NoError(t, <-serveErr)
+}
+
+func TestEngine_RunListeners(t *testing.T) {
+	public := NewEngine(WithShutdownSignals())
+	public.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "public")
+	})
+	var starts atomic.Int32
+	public.OnBeforeStart(func(ctx context.Context) error {
+		starts.Add(1)
+		return nil
+	})
+	admin := NewEngine(WithShutdownSignals())
+	admin.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "admin")
+	})
+
+	l1, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l2, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l3, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	publicErr := make(chan error, 1)
+	adminErr := make(chan error, 1)
+	go func() {
+		publicErr <- public.RunListeners(ctx, l1, l2)
+	}()
+	go func() {
+		adminErr <- admin.RunListeners(ctx, l3)
+	}()
+	require.Eventually(t, func() bool {
+		return public.Ready() && admin.Ready()
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "public", getBody(t, http.DefaultClient, "http://"+l1.Addr().String()+"/"))
+	assert.Equal(t, "public", getBody(t, http.DefaultClient, "http://"+l2.Addr().String()+"/"))
+	assert.Equal(t, "admin", getBody(t, http.DefaultClient, "http://"+l3.Addr().String()+"/"))
+	assert.Equal(t, int32(1), starts.Load())
+
+	cancel()
+	assert.NoError(t, <-publicErr)
+	assert.NoError(t, <-adminErr)
+	assert.False(t, public.Ready())
+	_, err = net.Dial("tcp", l2.Addr().String())
+	assert.Error(t, err)
+
+	assert.Error(t, NewEngine().RunListeners(context.Background()))
+}
+
+func TestAddrMatches(t *testing.T) {
+	testCases := []struct {
+		name    string
+		network string
+		addr    string
+		la      net.Addr
+		want    bool
+	}{
+		{
+			name:    "same ip and port",
+			network: "tcp",
+			addr:    "127.0.0.1:8080",
+			la:      &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8080},
+			want:    true,
+		},
+		{
+			name:    "any address",
+			network: "tcp",
+			addr:    ":8080",
+			la:      &net.TCPAddr{IP: net.IPv6unspecified, Port: 8080},
+			want:    true,
+		},
+		{
+			name:    "different port",
+			network: "tcp",
+			addr:    ":8081",
+			la:      &net.TCPAddr{IP: net.IPv6unspecified, Port: 8080},
+		},
+		{
+			name:    "different ip",
+			network: "tcp",
+			addr:    "127.0.0.1:8080",
+			la:      &net.TCPAddr{IP: net.IPv6unspecified, Port: 8080},
+		},
+		{
+			name:    "random port",
+			network: "tcp",
+			addr:    "127.0.0.1:0",
+			la:      &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8080},
+		},
+		{
+			name:    "unix",
+			network: "unix",
+			addr:    "/run/web.sock",
+			la:      &net.UnixAddr{Net: "unix", Name: "/run/web.sock"},
+			want:    true,
+		},
+		{
+			name:    "network mismatch",
+			network: "tcp",
+			addr:    "/run/web.sock",
+			la:      &net.UnixAddr{Net: "unix", Name: "/run/web.sock"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, addrMatches(tc.network, tc.addr, tc.la))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+//go:build unix
+
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_StartUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "web.sock")
+	// 上次未正常退出遗留的 socket 文件
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	e := NewEngine()
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "unix")
+	})
+	go func() {
+		_ = e.StartUnix(path, 0o660)
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	assert.Equal(t, "unix", getBody(t, client, "http://unix/"))
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// 不是 socket 的文件不会被删除
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0o600))
+	_, err = ListenUnix(file, 0o600)
+	assert.Error(t, err)
+	_, err = os.Stat(file)
+	assert.NoError(t, err)
+}
+
+func TestSystemdListeners(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer tcp.Close()
+	f, err := tcp.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer f.Close()
+
+	listeners, err := listenersFromFDs(int(f.Fd()), 1, "public")
+	require.NoError(t, err)
+	require.Len(t, listeners, 1)
+	assert.Equal(t, "public", listeners[0].Name)
+	assert.Equal(t, tcp.Addr().String(), listeners[0].Addr().String())
+
+	e := NewEngine()
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "activated")
+	})
+	go func() {
+		_ = e.Serve(listeners[0])
+	}()
+	defer func() {
+		_ = e.Shutdown(context.Background())
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "activated", getBody(t, http.DefaultClient, "http://"+tcp.Addr().String()+"/"))
+
+	// 文件描述符不是 socket
+	devNull, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer devNull.Close()
+	_, err = listenersFromFDs(int(devNull.Fd()), 1, "")
+	assert.Error(t, err)
+
+	// 传递给其他进程的文件描述符
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, err = SystemdListeners()
+	assert.NoError(t, err)
+	assert.Empty(t, listeners)
+	assert.Equal(t, "1", os.Getenv("LISTEN_FDS"))
+
+	// 没有传递文件描述符时清除环境变量
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "0")
+	listeners, err = SystemdListeners()
+	assert.NoError(t, err)
+	assert.Empty(t, listeners)
+	assert.Empty(t, os.Getenv("LISTEN_FDS"))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
 	"log"
+	"net/http"
 	"runtime"
 	"strconv"
 	"strings"
@@ -36,11 +37,12 @@ func (l LoggerBuilder) Build() HandleFunc {
 		ctx.Next()
 		defer func() {
 			al := accessLog{
-				Host:    ctx.Req.Host,
-				Route:   ctx.MatchedRoute,
-				Method:  ctx.Req.Method,
-				Path:    ctx.Req.URL.Path,
-				Latency: time.Since(startTime),
+				Host:     ctx.Req.Host,
+				Route:    ctx.MatchedRoute,
+				Method:   ctx.Req.Method,
+				Path:     ctx.Req.URL.Path,
+				ClientIP: ctx.ClientIP(),
+				Latency:  time.Since(startTime),
 			}
 			data, _ := json.Marshal(al)
 			l.LogFunc(string(data))
@@ -50,11 +52,12 @@ func (l LoggerBuilder) Build() HandleFunc {
 
 // accessLog 访问日志结构体
 type accessLog struct {
-	Host    string        // 主机
-	Route   string        // 路由
-	Method  string        // HTTP方法
-	Path    string        // 请求路径
-	Latency time.Duration //响应时间
+	Host     string        // 主机
+	Route    string        // 路由
+	Method   string        // HTTP方法
+	Path     string        // 请求路径
+	ClientIP string        // 客户端IP
+	Latency  time.Duration //响应时间
 }
 
 // PrometheusBuilder Prometheus监控中间件构建器
@@ -106,6 +109,10 @@ type RecoverBuilder struct {
 
 // DefaultRecoverHandler 默认的恢复处理函数
 func DefaultRecoverHandler(ctx *Context) {
+	if ctx.useProblem() {
+		_ = ctx.Problem(NewProblem(http.StatusInternalServerError, ""))
+		return
+	}
 	ctx.StatusCode = 500
 	ctx.RespData = []byte("Internal Server Error")
 }
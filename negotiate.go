@@ -0,0 +1,173 @@
+package web
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 内容协商支持的响应格式
+const (
+	MIMEJSON = "application/json"
+	MIMEXML  = "application/xml"
+	MIMEText = "text/plain"
+)
+
+// ErrNotAcceptable 请求的 Accept 中没有可以提供的响应格式
+var ErrNotAcceptable error = notAcceptableError{}
+
+var _ StatusCoder = notAcceptableError{}
+
+// notAcceptableError 没有可以接受的响应格式的错误，响应 406
+type notAcceptableError struct{}
+
+func (notAcceptableError) Error() string {
+	return "no acceptable response format"
+}
+
+// StatusCode 返回 406
+func (notAcceptableError) StatusCode() int {
+	return http.StatusNotAcceptable
+}
+
+// XML 发送XML格式的响应
+func (c *Context) XML(status int, val any) error {
+	data, err := xml.Marshal(val)
+	if err != nil {
+		return err
+	}
+	c.Resp.Header().Set("Content-Type", MIMEXML)
+	c.StatusCode = status
+	c.RespData = data
+	return nil
+}
+
+// acceptRange Accept 中的一项
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept 解析 Accept 请求头，按 q 值从高到低排序，q 为 0 的项表示不接受该类型
+func parseAccept(header string) []acceptRange {
+	var res []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if val, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(val, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		res = append(res, acceptRange{mediaType: mediaType, q: q})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].q > res[j].q
+	})
+	return res
+}
+
+// mediaTypeSpecificity 返回 Accept 中的一项匹配 offer 的精确程度，不匹配时返回 -1
+// 完全相同为 2，type/* 为 1，*/* 为 0
+func mediaTypeSpecificity(accept, offer string) int {
+	switch {
+	case accept == offer:
+		return 2
+	case accept == "*/*":
+		return 0
+	}
+	if prefix, ok := strings.CutSuffix(accept, "/*"); ok && strings.HasPrefix(offer, prefix+"/") {
+		return 1
+	}
+	return -1
+}
+
+// acceptQuality 返回 offer 的 q 值和决定该值的项在 ranges 中的位置
+// 由匹配最精确的一项决定，例如 application/json;q=0, */* 不接受 JSON；没有匹配时 q 为 0
+func acceptQuality(ranges []acceptRange, offer string) (float64, int) {
+	q, idx, specificity := 0.0, len(ranges), -1
+	for i, r := range ranges {
+		if s := mediaTypeSpecificity(r.mediaType, offer); s > specificity {
+			q, idx, specificity = r.q, i, s
+		}
+	}
+	return q, idx
+}
+
+// prefersHTML 判断客户端是否首选 HTML 而 offers 中没有 HTML，通常是浏览器直接访问
+// 浏览器的 Accept 中 application/xml 等只是默认值，不代表真正的偏好
+func prefersHTML(ranges []acceptRange, offers []string) bool {
+	for _, offer := range offers {
+		if offer == "text/html" {
+			return false
+		}
+	}
+	for _, r := range ranges {
+		if r.q < ranges[0].q {
+			break
+		}
+		if r.mediaType == "text/html" {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateFormat 根据 Accept 请求头从 offers 中选择响应格式
+// 选择 q 值最高的 offer，q 值相同时依次按 Accept 中的顺序和 offers 的顺序；
+// 没有 Accept 请求头或者客户端首选 offers 中没有的 HTML 时返回第一个可以接受的 offer，
+// 没有可以接受的格式时返回空字符串
+func (c *Context) NegotiateFormat(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	header := c.GetHeader("Accept")
+	if header == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(header)
+	if len(ranges) > 0 && prefersHTML(ranges, offers) {
+		for _, offer := range offers {
+			if q, _ := acceptQuality(ranges, offer); q > 0 {
+				return offer
+			}
+		}
+		return ""
+	}
+	best, bestQ, bestIdx := "", 0.0, len(ranges)
+	for _, offer := range offers {
+		q, idx := acceptQuality(ranges, offer)
+		if q > bestQ || q == bestQ && q > 0 && idx < bestIdx {
+			best, bestQ, bestIdx = offer, q, idx
+		}
+	}
+	return best
+}
+
+// Negotiate 根据 Accept 请求头以 JSON、XML 或文本发送响应，默认为 JSON
+// 没有可以接受的格式时返回 ErrNotAcceptable
+func (c *Context) Negotiate(status int, val any) error {
+	switch c.NegotiateFormat(MIMEJSON, MIMEXML, "text/xml", MIMEText) {
+	case MIMEJSON:
+		return c.JSON(status, val)
+	case MIMEXML, "text/xml":
+		return c.XML(status, val)
+	case MIMEText:
+		if s, ok := val.(interface{ String() string }); ok {
+			return c.String(status, s.String())
+		}
+		if s, ok := val.(string); ok {
+			return c.String(status, s)
+		}
+		// 无法以文本表示的值仍然使用 JSON
+		return c.JSON(status, val)
+	default:
+		return ErrNotAcceptable
+	}
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_NegotiateFormat(t *testing.T) {
+	testCases := []struct {
+		name   string
+		accept string
+		offers []string
+		want   string
+	}{
+		{name: "no accept", offers: []string{MIMEJSON, MIMEXML}, want: MIMEJSON},
+		{name: "exact", accept: "application/xml", offers: []string{MIMEJSON, MIMEXML}, want: MIMEXML},
+		{name: "quality", accept: "application/json;q=0.5, application/xml", offers: []string{MIMEJSON, MIMEXML}, want: MIMEXML},
+		{name: "any", accept: "*/*", offers: []string{MIMEXML, MIMEJSON}, want: MIMEXML},
+		{name: "type wildcard", accept: "text/*", offers: []string{MIMEJSON, MIMEText}, want: MIMEText},
+		{name: "q zero excludes", accept: "application/json;q=0, */*", offers: []string{MIMEJSON, MIMEXML}, want: MIMEXML},
+		{name: "q zero only", accept: "application/json;q=0", offers: []string{MIMEJSON}, want: ""},
+		{name: "specific over wildcard", accept: "text/*;q=0.2, text/plain;q=0.8, */*;q=0.5", offers: []string{"text/xml", MIMEText}, want: MIMEText},
+		{name: "accept order", accept: "application/xml, application/json", offers: []string{MIMEJSON, MIMEXML}, want: MIMEXML},
+		{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", offers: []string{MIMEJSON, MIMEXML}, want: MIMEJSON},
+		{name: "browser html offered", accept: "text/html,application/xml;q=0.9,*/*;q=0.8", offers: []string{MIMEJSON, "text/html"}, want: "text/html"},
+		{name: "not acceptable", accept: "image/png", offers: []string{MIMEJSON, MIMEXML}, want: ""},
+		{name: "invalid parts ignored", accept: "bad;;, application/xml", offers: []string{MIMEJSON, MIMEXML}, want: MIMEXML},
+		{name: "no offers", accept: "*/*", want: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			ctx := newContext(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.want, ctx.NegotiateFormat(tc.offers...))
+		})
+	}
+}
+
+type negotiateItem struct {
+	XMLName xml.Name `json:"-" xml:"item"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func (i negotiateItem) String() string {
+	return "item " + i.Name
+}
+
+func TestContext_Negotiate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		accept   string
+		val      any
+		wantType string
+		wantBody string
+		wantErr  error
+	}{
+		{name: "default json", val: negotiateItem{Name: "tom"}, wantType: MIMEJSON, wantBody: `{"name":"tom"}`},
+		{name: "xml", accept: "application/xml", val: negotiateItem{Name: "tom"}, wantType: MIMEXML, wantBody: `<item><name>tom</name></item>`},
+		{name: "text xml", accept: "text/xml", val: negotiateItem{Name: "tom"}, wantType: MIMEXML, wantBody: `<item><name>tom</name></item>`},
+		{name: "text stringer", accept: "text/plain", val: negotiateItem{Name: "tom"}, wantType: "text/plain", wantBody: "item tom"},
+		{name: "text string", accept: "text/plain", val: "hello", wantType: "text/plain", wantBody: "hello"},
+		{name: "text fallback json", accept: "text/plain", val: map[string]int{"a": 1}, wantType: MIMEJSON, wantBody: `{"a":1}`},
+		{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", val: map[string]int{"a": 1},
+			wantType: MIMEJSON, wantBody: `{"a":1}`},
+		{name: "json excluded", accept: "application/json;q=0, */*", val: negotiateItem{Name: "tom"}, wantType: MIMEXML, wantBody: `<item><name>tom</name></item>`},
+		{name: "not acceptable", accept: "image/png", val: negotiateItem{}, wantErr: ErrNotAcceptable},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			ctx := newContext(httptest.NewRecorder(), req)
+			err := ctx.Negotiate(http.StatusOK, tc.val)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				var sc StatusCoder
+				require.ErrorAs(t, err, &sc)
+				assert.Equal(t, http.StatusNotAcceptable, sc.StatusCode())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, ctx.StatusCode)
+			assert.Equal(t, tc.wantType, ctx.Resp.Header().Get("Content-Type"))
+			assert.Equal(t, tc.wantBody, string(ctx.RespData))
+		})
+	}
+}
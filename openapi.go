@@ -0,0 +1,634 @@
+package web
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Andras5014/go-web/openapi"
+)
+
+// describers Doc 和 Typed 生成的处理函数的代码地址
+// 同一个函数字面量创建的闭包代码地址相同，集合的大小只与 Typed 的实例化数量有关，不会持有处理函数
+var describers sync.Map
+
+// describable 将处理函数标记为可以描述自身，注册路由时会以描述模式调用
+func describable(h HandleFunc) HandleFunc {
+	describers.Store(reflect.ValueOf(h).Pointer(), struct{}{})
+	return h
+}
+
+// routeDoc 路由的文档信息，注册路由时从 Doc 和 Typed 生成的处理函数中收集
+type routeDoc struct {
+	route *RouteDoc    // 通过 Doc 设置的文档
+	req   reflect.Type // Typed 处理函数的请求类型
+	resp  reflect.Type // Typed 处理函数的响应类型
+}
+
+// describeRoute 以描述模式调用路由上 Doc 和 Typed 生成的处理函数，收集文档信息，其他处理函数不会被调用
+func describeRoute(handlers []HandleFunc) *routeDoc {
+	doc := &routeDoc{route: &RouteDoc{}}
+	ctx := &Context{describe: doc}
+	for _, h := range handlers {
+		if _, ok := describers.Load(reflect.ValueOf(h).Pointer()); ok {
+			h(ctx)
+		}
+	}
+	return doc
+}
+
+// RouteDoc 路由的文档信息
+type RouteDoc struct {
+	Summary     string
+	Description string
+	OperationID string
+	Tags        []string
+	Security    []openapi.SecurityRequirement
+	Deprecated  bool
+	Hidden      bool // 不出现在 OpenAPI 文档中
+
+	responses map[int]docResponse // 通过 DocResponse 添加的响应
+}
+
+// docResponse 通过 DocResponse 添加的响应
+type docResponse struct {
+	description string
+	body        reflect.Type // 响应体的类型，生成文档时转换为 Schema
+}
+
+// DocOption 路由文档的可选项
+type DocOption func(d *RouteDoc)
+
+// DocSummary 设置接口的简介
+func DocSummary(summary string) DocOption {
+	return func(d *RouteDoc) {
+		d.Summary = summary
+	}
+}
+
+// DocDescription 设置接口的详细描述
+func DocDescription(desc string) DocOption {
+	return func(d *RouteDoc) {
+		d.Description = desc
+	}
+}
+
+// DocOperationID 设置接口的唯一标识
+func DocOperationID(id string) DocOption {
+	return func(d *RouteDoc) {
+		d.OperationID = id
+	}
+}
+
+// DocTags 添加接口的标签
+func DocTags(tags ...string) DocOption {
+	return func(d *RouteDoc) {
+		d.Tags = append(d.Tags, tags...)
+	}
+}
+
+// DocSecurity 添加接口的认证要求，scheme 为 WithSecurityScheme 注册的认证方式名
+func DocSecurity(scheme string, scopes ...string) DocOption {
+	return func(d *RouteDoc) {
+		if scopes == nil {
+			scopes = []string{}
+		}
+		d.Security = append(d.Security, openapi.SecurityRequirement{scheme: scopes})
+	}
+}
+
+// DocDeprecated 将接口标记为已废弃
+func DocDeprecated() DocOption {
+	return func(d *RouteDoc) {
+		d.Deprecated = true
+	}
+}
+
+// DocHidden 不在 OpenAPI 文档中展示接口
+func DocHidden() DocOption {
+	return func(d *RouteDoc) {
+		d.Hidden = true
+	}
+}
+
+// DocResponse 添加接口的响应，body 为响应体的示例值，用于推断 Schema，为 nil 时表示没有响应体
+func DocResponse(status int, description string, body any) DocOption {
+	return func(d *RouteDoc) {
+		if d.responses == nil {
+			d.responses = make(map[int]docResponse)
+		}
+		d.responses[status] = docResponse{description: description, body: reflect.TypeOf(body)}
+	}
+}
+
+// handle 文档中间件本身不做任何处理，描述模式下将文档合并到路由中
+func (d *RouteDoc) handle(ctx *Context) {
+	if ctx.describe != nil {
+		ctx.describe.route.merge(d)
+		return
+	}
+	ctx.Next()
+}
+
+// Doc 返回携带文档信息的中间件，可以用于路由，也可以通过 Use 用于路由组
+// 同一路由上的多个 Doc 会合并：标签和认证要求会累加，其他字段以最后设置的为准
+//
+//	g.Use(web.Doc(web.DocTags("users"), web.DocSecurity("bearer")))
+//	g.GET("/:id", web.Doc(web.DocSummary("Get user")), web.Typed(getUser))
+func Doc(opts ...DocOption) HandleFunc {
+	doc := &RouteDoc{}
+	for _, opt := range opts {
+		opt(doc)
+	}
+	return describable(doc.handle)
+}
+
+// merge 合并另一个文档信息
+func (d *RouteDoc) merge(other *RouteDoc) {
+	if other.Summary != "" {
+		d.Summary = other.Summary
+	}
+	if other.Description != "" {
+		d.Description = other.Description
+	}
+	if other.OperationID != "" {
+		d.OperationID = other.OperationID
+	}
+	for _, tag := range other.Tags {
+		if !contains(d.Tags, tag) {
+			d.Tags = append(d.Tags, tag)
+		}
+	}
+	d.Security = append(d.Security, other.Security...)
+	d.Deprecated = d.Deprecated || other.Deprecated
+	d.Hidden = d.Hidden || other.Hidden
+	for status, resp := range other.responses {
+		if d.responses == nil {
+			d.responses = make(map[int]docResponse)
+		}
+		d.responses[status] = resp
+	}
+}
+
+// contains 判断 list 中是否有 val
+func contains(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAPIOption OpenAPI 文档的可选项
+type OpenAPIOption func(c *openAPIConfig)
+
+// openAPIConfig OpenAPI 文档的配置
+type openAPIConfig struct {
+	info            openapi.Info
+	servers         []openapi.Server
+	securitySchemes map[string]*openapi.SecurityScheme
+	security        []openapi.SecurityRequirement
+	path            string // 文档的路径
+	uiPath          string // 文档页面的路径，为空时不提供页面
+}
+
+// WithOpenAPIInfo 设置文档的标题、版本和描述，默认为 API 和 1.0.0
+func WithOpenAPIInfo(info openapi.Info) OpenAPIOption {
+	return func(c *openAPIConfig) {
+		c.info = info
+	}
+}
+
+// WithOpenAPIServers 设置服务地址
+func WithOpenAPIServers(servers ...openapi.Server) OpenAPIOption {
+	return func(c *openAPIConfig) {
+		c.servers = append(c.servers, servers...)
+	}
+}
+
+// WithSecurityScheme 注册认证方式，接口通过 DocSecurity 引用
+func WithSecurityScheme(name string, scheme *openapi.SecurityScheme) OpenAPIOption {
+	return func(c *openAPIConfig) {
+		c.securitySchemes[name] = scheme
+	}
+}
+
+// WithDefaultSecurity 设置所有接口默认的认证要求
+func WithDefaultSecurity(scheme string, scopes ...string) OpenAPIOption {
+	return func(c *openAPIConfig) {
+		if scopes == nil {
+			scopes = []string{}
+		}
+		c.security = append(c.security, openapi.SecurityRequirement{scheme: scopes})
+	}
+}
+
+// WithOpenAPIPath 设置 ServeOpenAPI 提供文档的路径，默认为 /openapi.json
+func WithOpenAPIPath(path string) OpenAPIOption {
+	return func(c *openAPIConfig) {
+		c.path = path
+	}
+}
+
+// WithDocsUI 设置 ServeOpenAPI 提供文档页面的路径，例如 /docs，默认不提供页面
+func WithDocsUI(path string) OpenAPIOption {
+	return func(c *openAPIConfig) {
+		c.uiPath = path
+	}
+}
+
+// newOpenAPIConfig 创建默认配置并应用可选项
+func newOpenAPIConfig(opts []OpenAPIOption) *openAPIConfig {
+	cfg := &openAPIConfig{
+		info:            openapi.Info{Title: "API", Version: "1.0.0"},
+		securitySchemes: make(map[string]*openapi.SecurityScheme),
+		path:            "/openapi.json",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// OpenAPI 根据已注册的路由生成 OpenAPI 3.1 文档
+// 路径参数来自路由，Typed 处理函数的请求和响应类型会被转换为参数、请求体和响应的 Schema，
+// 通过 Doc 设置的信息会合并到对应的接口中
+func (e *Engine) OpenAPI(opts ...OpenAPIOption) *openapi.Document {
+	cfg := newOpenAPIConfig(opts)
+	doc := &openapi.Document{
+		OpenAPI:  openapi.Version,
+		Info:     cfg.info,
+		Servers:  cfg.servers,
+		Paths:    make(map[string]*openapi.PathItem),
+		Security: cfg.security,
+	}
+	components := &openapi.Components{}
+	gen := &openAPIGenerator{engine: e, reflector: openapi.NewReflector(components)}
+
+	type route struct {
+		method string
+		node   *node
+	}
+	var routes []route
+	e.walk(func(method string, n *node) {
+		routes = append(routes, route{method: method, node: n})
+	})
+	// 按固定顺序生成，使同名类型的组件名稳定
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].node.route != routes[j].node.route {
+			return routes[i].node.route < routes[j].node.route
+		}
+		return routes[i].method < routes[j].method
+	})
+
+	var tags []string
+	for _, r := range routes {
+		op := gen.operation(r.method, r.node)
+		if op == nil {
+			continue
+		}
+		path := openAPIPath(r.node.route)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		item.SetOperation(r.method, op)
+		for _, tag := range op.Tags {
+			if !contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		doc.Tags = append(doc.Tags, openapi.Tag{Name: tag})
+	}
+
+	if len(cfg.securitySchemes) > 0 {
+		components.SecuritySchemes = cfg.securitySchemes
+	}
+	if len(components.Schemas) == 0 {
+		components.Schemas = nil
+	}
+	if components.Schemas != nil || components.SecuritySchemes != nil {
+		doc.Components = components
+	}
+	return doc
+}
+
+// openAPIPath 将路由转换为 OpenAPI 的路径模板，:id 转换为 {id}，通配符 * 转换为 {*}
+func openAPIPath(route string) string {
+	segs := strings.Split(route, "/")
+	for i, seg := range segs {
+		if seg == "*" {
+			segs[i] = "{*}"
+		} else if strings.HasPrefix(seg, ":") {
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// routeParams 返回路由中的路径参数名
+func routeParams(route string) []string {
+	var res []string
+	for _, seg := range strings.Split(route, "/") {
+		if seg == "*" {
+			res = append(res, "*")
+		} else if strings.HasPrefix(seg, ":") {
+			res = append(res, seg[1:])
+		}
+	}
+	return res
+}
+
+// openAPIGenerator 生成单个接口的文档
+type openAPIGenerator struct {
+	engine    *Engine
+	reflector *openapi.Reflector
+}
+
+// operation 生成路由对应的接口，隐藏的路由返回 nil
+func (g *openAPIGenerator) operation(method string, n *node) *openapi.Operation {
+	doc := n.doc
+	if doc == nil {
+		doc = &routeDoc{route: &RouteDoc{}}
+	}
+	route := doc.route
+	if route.Hidden {
+		return nil
+	}
+
+	op := &openapi.Operation{
+		Tags:        route.Tags,
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: route.OperationID,
+		Deprecated:  route.Deprecated,
+		Security:    route.Security,
+		Responses:   make(map[string]*openapi.Response),
+	}
+	if doc.req != nil {
+		op.Parameters = g.parameters(n.route, doc.req)
+		if method != http.MethodGet && method != http.MethodHead {
+			op.RequestBody = g.requestBody(doc.req)
+		}
+		status, resp := g.response(doc.resp)
+		op.Responses[strconv.Itoa(status)] = resp
+		op.Responses["default"] = g.errorResponse()
+	}
+	// 路由中存在但请求类型没有绑定的路径参数
+	for _, name := range routeParams(n.route) {
+		if !hasParam(op.Parameters, name, openapi.InPath) {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name: name, In: openapi.InPath, Required: true,
+				Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeString}},
+			})
+		}
+	}
+	for status, resp := range route.responses {
+		res := &openapi.Response{Description: resp.description}
+		if resp.body != nil {
+			res.Content = map[string]*openapi.MediaType{MIMEJSON: {Schema: g.reflector.Schema(resp.body)}}
+		}
+		op.Responses[strconv.Itoa(status)] = res
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = &openapi.Response{Description: http.StatusText(http.StatusOK)}
+	}
+	return op
+}
+
+// hasParam 判断参数列表中是否已经有参数
+func hasParam(params []*openapi.Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// structType 返回去掉指针后的结构体类型，不是结构体时返回 nil
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// parameters 根据请求类型字段的 path、query、header 标签生成参数
+func (g *openAPIGenerator) parameters(route string, req reflect.Type) []*openapi.Parameter {
+	t := structType(req)
+	if t == nil {
+		return nil
+	}
+	pathParams := routeParams(route)
+	var res []*openapi.Parameter
+	for _, f := range bindFields(t) {
+		field := t.FieldByIndex(f.index)
+		for _, source := range []string{SourcePath, SourceQuery, SourceHeader} {
+			name, ok := f.names[source]
+			if !ok || (source == SourcePath && !contains(pathParams, name)) {
+				continue
+			}
+			schema := g.reflector.FieldSchema(field)
+			p := &openapi.Parameter{Name: name, In: source, Schema: schema, Description: schema.Description}
+			schema.Description = ""
+			p.Required = source == SourcePath
+			if val, ok := field.Tag.Lookup("required"); ok && source != SourcePath {
+				p.Required, _ = strconv.ParseBool(val)
+			}
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// isParamField 判断字段是否通过参数标签绑定
+func isParamField(f reflect.StructField) bool {
+	for _, source := range paramSources {
+		if _, ok := f.Tag.Lookup(source); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// requestBody 根据请求类型生成请求体，没有需要从请求体解析的字段时返回 nil
+func (g *openAPIGenerator) requestBody(req reflect.Type) *openapi.RequestBody {
+	content := make(map[string]*openapi.MediaType)
+	t := structType(req)
+	switch {
+	case t == nil:
+		content[MIMEJSON] = &openapi.MediaType{Schema: g.reflector.Schema(req)}
+	case !hasParamFields(t):
+		if t.NumField() > 0 {
+			content[MIMEJSON] = &openapi.MediaType{Schema: g.reflector.Schema(req)}
+		}
+	default:
+		// 带有参数标签的字段不属于请求体
+		schema := g.reflector.StructSchema(t, func(f reflect.StructField) bool {
+			return f.Anonymous || !isParamField(f)
+		})
+		if len(schema.Properties) > 0 {
+			content[MIMEJSON] = &openapi.MediaType{Schema: schema}
+		}
+		if form := g.formSchema(t); form != nil {
+			content["application/x-www-form-urlencoded"] = &openapi.MediaType{Schema: form}
+			content["multipart/form-data"] = &openapi.MediaType{Schema: form}
+		}
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return &openapi.RequestBody{Content: content}
+}
+
+// hasParamFields 判断结构体是否有通过参数标签绑定的字段
+func hasParamFields(t reflect.Type) bool {
+	return len(bindFields(t)) > 0
+}
+
+// formSchema 根据 form 标签生成表单的 Schema，没有 form 标签时返回 nil
+func (g *openAPIGenerator) formSchema(t reflect.Type) *openapi.Schema {
+	res := &openapi.Schema{Type: openapi.Types{openapi.TypeObject}, Properties: make(map[string]*openapi.Schema)}
+	for _, f := range bindFields(t) {
+		name, ok := f.names[SourceForm]
+		if !ok {
+			continue
+		}
+		res.Properties[name] = g.reflector.FieldSchema(t.FieldByIndex(f.index))
+	}
+	if len(res.Properties) == 0 {
+		return nil
+	}
+	return res
+}
+
+// response 根据响应类型生成成功的响应，状态码与 Typed 的默认状态码一致
+func (g *openAPIGenerator) response(resp reflect.Type) (int, *openapi.Response) {
+	status := http.StatusOK
+	if sc, ok := zeroValue(resp).(StatusCoder); ok {
+		status = statusOf(sc)
+	}
+	res := &openapi.Response{Description: http.StatusText(status)}
+	if resp.Kind() == reflect.Interface && resp.NumMethod() == 0 {
+		// any 类型的响应体无法推断
+		res.Content = map[string]*openapi.MediaType{MIMEJSON: {Schema: &openapi.Schema{}}}
+		return status, res
+	}
+	schema := g.reflector.Schema(resp)
+	res.Content = map[string]*openapi.MediaType{
+		MIMEJSON: {Schema: schema},
+		MIMEXML:  {Schema: schema},
+	}
+	return status, res
+}
+
+// zeroValue 返回类型的零值，指针类型返回指向零值的指针
+func zeroValue(t reflect.Type) any {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if t.Kind() == reflect.Pointer {
+		return reflect.New(t.Elem()).Interface()
+	}
+	return reflect.Zero(t).Interface()
+}
+
+// statusOf 调用 StatusCode，用户实现可能依赖字段的值，panic 时使用 200
+func statusOf(sc StatusCoder) (status int) {
+	defer func() {
+		if recover() != nil {
+			status = http.StatusOK
+		}
+	}()
+	if status = sc.StatusCode(); status == 0 {
+		status = http.StatusOK
+	}
+	return status
+}
+
+// problemSchema 返回问题详情的 Schema
+func problemSchema() *openapi.Schema {
+	return &openapi.Schema{
+		Type: openapi.Types{openapi.TypeObject},
+		Properties: map[string]*openapi.Schema{
+			"type":     {Type: openapi.Types{openapi.TypeString}, Format: "uri-reference"},
+			"title":    {Type: openapi.Types{openapi.TypeString}},
+			"status":   {Type: openapi.Types{openapi.TypeInteger}},
+			"detail":   {Type: openapi.Types{openapi.TypeString}},
+			"instance": {Type: openapi.Types{openapi.TypeString}, Format: "uri-reference"},
+		},
+	}
+}
+
+// errorResponse Typed 处理函数的错误响应，开启 WithProblemDetails 时为 problem+json
+func (g *openAPIGenerator) errorResponse() *openapi.Response {
+	res := &openapi.Response{Description: "Error"}
+	if g.engine.problemDetails {
+		schemas := g.reflector.Components.Schemas
+		if _, ok := schemas["Problem"]; !ok {
+			schemas["Problem"] = problemSchema()
+		}
+		res.Content = map[string]*openapi.MediaType{
+			ProblemContentType: {Schema: &openapi.Schema{Ref: "#/components/schemas/Problem"}},
+		}
+		return res
+	}
+	res.Content = map[string]*openapi.MediaType{
+		MIMEText: {Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeString}}},
+	}
+	return res
+}
+
+//go:embed assets/docs.html
+var docsPage string
+
+// docsTemplate 文档页面的模板
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// ServeOpenAPI 注册提供 OpenAPI 文档的路由，默认为 /openapi.json，通过 WithDocsUI 可以同时提供文档页面
+// 文档在第一次请求时生成，之后注册的路由不会出现在文档中；这些路由本身不会出现在文档中
+func (e *Engine) ServeOpenAPI(opts ...OpenAPIOption) {
+	cfg := newOpenAPIConfig(opts)
+	var (
+		once sync.Once
+		data []byte
+		err  error
+	)
+	e.GET(cfg.path, Doc(DocHidden()), func(ctx *Context) {
+		once.Do(func() {
+			data, err = json.Marshal(e.OpenAPI(opts...))
+		})
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		_ = ctx.Data(http.StatusOK, MIMEJSON, data)
+	})
+	if cfg.uiPath == "" {
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err := docsTemplate.Execute(buf, map[string]string{"Title": cfg.info.Title, "SpecURL": cfg.path}); err != nil {
+		panic(err)
+	}
+	page := buf.Bytes()
+	e.GET(cfg.uiPath, Doc(DocHidden()), func(ctx *Context) {
+		_ = ctx.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+}
@@ -0,0 +1,337 @@
+// Package openapi OpenAPI 3 文档模型，以及根据 Go 类型生成 JSON Schema
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version 生成的文档使用的 OpenAPI 版本
+const Version = "3.1.0"
+
+// Document OpenAPI 文档
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths,omitempty"`
+	Components *Components           `json:"components,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+}
+
+// Info 文档的基本信息
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server 服务地址
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag 标签的描述
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem 一个路径上的所有操作
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Trace      *Operation   `json:"trace,omitempty"`
+}
+
+// operation 返回请求方法对应的操作字段
+func (p *PathItem) operation(method string) **Operation {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	}
+	return nil
+}
+
+// Operation 返回请求方法对应的操作，没有时返回 nil
+func (p *PathItem) Operation(method string) *Operation {
+	if op := p.operation(method); op != nil {
+		return *op
+	}
+	return nil
+}
+
+// SetOperation 设置请求方法对应的操作，不支持的请求方法会被忽略
+func (p *PathItem) SetOperation(method string, op *Operation) {
+	if field := p.operation(method); field != nil {
+		*field = op
+	}
+}
+
+// Operation 一个接口
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// 参数位置
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InCookie = "cookie"
+)
+
+// Parameter 接口的参数
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 某种内容类型的数据结构
+type MediaType struct {
+	Schema  *Schema `json:"schema,omitempty"`
+	Example any     `json:"example,omitempty"`
+}
+
+// Response 响应
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header 响应头
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// Components 可复用的定义
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	RequestBodies   map[string]*RequestBody    `json:"requestBodies,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 认证方式
+type SecurityScheme struct {
+	Type             string `json:"type"`
+	Description      string `json:"description,omitempty"`
+	Name             string `json:"name,omitempty"`
+	In               string `json:"in,omitempty"`
+	Scheme           string `json:"scheme,omitempty"`
+	BearerFormat     string `json:"bearerFormat,omitempty"`
+	OpenIDConnectURL string `json:"openIdConnectUrl,omitempty"`
+}
+
+// SecurityRequirement 认证要求，认证方式名 -> 需要的 scope
+type SecurityRequirement map[string][]string
+
+// componentPrefix 组件引用的前缀
+const componentPrefix = "#/components/"
+
+// componentName 解析 #/components/<kind>/<name> 形式的引用
+func componentName(ref, kind string) (string, error) {
+	name, ok := strings.CutPrefix(ref, componentPrefix+kind+"/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("openapi: unsupported reference %q", ref)
+	}
+	return name, nil
+}
+
+// ResolveSchema 解析 Schema 的 $ref，没有引用时返回 s
+func (d *Document) ResolveSchema(s *Schema) (*Schema, error) {
+	for seen := 0; s != nil && s.Ref != ""; seen++ {
+		if seen > 32 {
+			return nil, fmt.Errorf("openapi: reference cycle at %q", s.Ref)
+		}
+		name, err := componentName(s.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		var res *Schema
+		if d.Components != nil {
+			res = d.Components.Schemas[name]
+		}
+		if res == nil {
+			return nil, fmt.Errorf("openapi: schema %q not found", name)
+		}
+		s = res
+	}
+	return s, nil
+}
+
+// ResolveParameter 解析参数的 $ref，没有引用时返回 p
+func (d *Document) ResolveParameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := componentName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	if d.Components == nil || d.Components.Parameters[name] == nil {
+		return nil, fmt.Errorf("openapi: parameter %q not found", name)
+	}
+	return d.Components.Parameters[name], nil
+}
+
+// ResolveRequestBody 解析请求体的 $ref，没有引用时返回 b
+func (d *Document) ResolveRequestBody(b *RequestBody) (*RequestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	name, err := componentName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	if d.Components == nil || d.Components.RequestBodies[name] == nil {
+		return nil, fmt.Errorf("openapi: request body %q not found", name)
+	}
+	return d.Components.RequestBodies[name], nil
+}
+
+// ResolveResponse 解析响应的 $ref，没有引用时返回 r
+func (d *Document) ResolveResponse(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := componentName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	if d.Components == nil || d.Components.Responses[name] == nil {
+		return nil, fmt.Errorf("openapi: response %q not found", name)
+	}
+	return d.Components.Responses[name], nil
+}
+
+// Parse 解析 JSON 或 YAML 格式的文档
+func Parse(data []byte) (*Document, error) {
+	var raw any
+	// YAML 是 JSON 的超集，统一转换为 JSON 后解析
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	data, err := json.Marshal(stringKeys(raw))
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	doc := &Document{}
+	if err = json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q", doc.OpenAPI)
+	}
+	return doc, nil
+}
+
+// stringKeys 将 YAML 中非字符串的键转换为字符串，例如响应状态码 200
+func stringKeys(val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+	case map[any]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return res
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return val
+}
+
+// Load 读取并解析文档文件
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// YAML 将文档编码为 YAML
+func (d *Document) YAML() ([]byte, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var raw yaml.Node
+	if err = yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	blockStyle(&raw)
+	return yaml.Marshal(&raw)
+}
+
+// blockStyle 去掉从 JSON 解析得到的流式风格和引号，使输出为常见的 YAML 格式
+func blockStyle(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode {
+		n.Style &^= yaml.DoubleQuotedStyle | yaml.FlowStyle
+	} else {
+		n.Style &^= yaml.FlowStyle
+	}
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
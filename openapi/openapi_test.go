@@ -0,0 +1,136 @@
+package openapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: "1.0"
+paths:
+  /pets/{id}:
+    parameters:
+      - $ref: '#/components/parameters/PetID'
+    get:
+      responses:
+        200:
+          $ref: '#/components/responses/Pet'
+components:
+  parameters:
+    PetID:
+      name: id
+      in: path
+      required: true
+      schema:
+        type: integer
+  responses:
+    Pet:
+      description: A pet
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Pet'
+  schemas:
+    Pet:
+      type: object
+      additionalProperties: false
+      properties:
+        name:
+          type: [string, "null"]
+`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(petstore))
+	require.NoError(t, err)
+	assert.Equal(t, "Petstore", doc.Info.Title)
+
+	item := doc.Paths["/pets/{id}"]
+	require.NotNil(t, item)
+	param, err := doc.ResolveParameter(item.Parameters[0])
+	require.NoError(t, err)
+	assert.Equal(t, "id", param.Name)
+	assert.Equal(t, Types{TypeInteger}, param.Schema.Type)
+
+	op := item.Operation("GET")
+	require.NotNil(t, op)
+	assert.Nil(t, item.Operation("POST"))
+	resp, err := doc.ResolveResponse(op.Responses["200"])
+	require.NoError(t, err)
+	schema, err := doc.ResolveSchema(resp.Content["application/json"].Schema)
+	require.NoError(t, err)
+	assert.Equal(t, Types{TypeString, TypeNull}, schema.Properties["name"].Type)
+	assert.Equal(t, False(), schema.AdditionalProperties)
+
+	_, err = doc.ResolveSchema(&Schema{Ref: "#/components/schemas/Missing"})
+	assert.Error(t, err)
+	_, err = doc.ResolveSchema(&Schema{Ref: "other.yaml#/Pet"})
+	assert.Error(t, err)
+	_, err = doc.ResolveRequestBody(&RequestBody{Ref: "#/components/requestBodies/Missing"})
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(`{"openapi": "2.0"}`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`: bad`))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"openapi":"3.1.0","info":{"title":"t","version":"1"}}`), 0o600))
+	doc, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestDocument_YAML(t *testing.T) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "API", Version: "1.0.0"},
+		Paths: map[string]*PathItem{
+			"/ping": {Get: &Operation{Responses: map[string]*Response{"200": {Description: "OK"}}}},
+		},
+	}
+	data, err := doc.YAML()
+	require.NoError(t, err)
+	assert.Equal(t, `openapi: 3.1.0
+info:
+    title: API
+    version: 1.0.0
+paths:
+    /ping:
+        get:
+            responses:
+                "200":
+                    description: OK
+`, string(data))
+
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, doc, parsed)
+}
+
+func TestPathItem_SetOperation(t *testing.T) {
+	item := &PathItem{}
+	for _, method := range []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"} {
+		op := &Operation{Summary: method}
+		item.SetOperation(method, op)
+		assert.Same(t, op, item.Operation(method))
+	}
+	item.SetOperation("CONNECT", &Operation{})
+	assert.Nil(t, item.Operation("CONNECT"))
+
+	data, err := json.Marshal(item)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"trace":{"summary":"TRACE"}`)
+}
@@ -0,0 +1,420 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSON Schema 的类型
+const (
+	TypeNull    = "null"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeNumber  = "number"
+	TypeString  = "string"
+	TypeInteger = "integer"
+)
+
+// Types Schema 的类型，只有一个类型时编码为字符串
+type Types []string
+
+// MarshalJSON 只有一个类型时编码为字符串
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON 支持字符串和数组两种形式
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// Has 判断是否包含类型 typ
+func (t Types) Has(typ string) bool {
+	for _, v := range t {
+		if v == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema JSON Schema，OpenAPI 3.1 使用 JSON Schema 2020-12，同时兼容 3.0 的 nullable
+type Schema struct {
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        Types  `json:"type,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Nullable    bool   `json:"nullable,omitempty"`
+	Enum        []any  `json:"enum,omitempty"`
+	Const       any    `json:"const,omitempty"`
+	Default     any    `json:"default,omitempty"`
+	Example     any    `json:"example,omitempty"`
+	Examples    []any  `json:"examples,omitempty"`
+	ReadOnly    bool   `json:"readOnly,omitempty"`
+	WriteOnly   bool   `json:"writeOnly,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+	MinLength        *int     `json:"minLength,omitempty"`
+	MaxLength        *int     `json:"maxLength,omitempty"`
+	Pattern          string   `json:"pattern,omitempty"`
+
+	Items       *Schema `json:"items,omitempty"`
+	MinItems    *int    `json:"minItems,omitempty"`
+	MaxItems    *int    `json:"maxItems,omitempty"`
+	UniqueItems bool    `json:"uniqueItems,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+}
+
+// False 不匹配任何值的 Schema，编码为 false
+func False() *Schema {
+	return &Schema{Not: &Schema{}}
+}
+
+// isFalse 判断是否为 False 返回的 Schema
+func (s *Schema) isFalse() bool {
+	return s.Not != nil && reflect.DeepEqual(*s.Not, Schema{}) &&
+		reflect.DeepEqual(*s, Schema{Not: s.Not})
+}
+
+// MarshalJSON False 编码为 false
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.isFalse() {
+		return []byte("false"), nil
+	}
+	type schema Schema
+	return json.Marshal((*schema)(s))
+}
+
+// UnmarshalJSON 支持 true 和 false 形式的布尔 Schema
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = *False()
+		return nil
+	}
+	type schema Schema
+	// OpenAPI 3.0 的 exclusiveMinimum 和 exclusiveMaximum 是布尔值，表示 minimum 和 maximum 是否不包含边界
+	aux := struct {
+		*schema
+		ExclusiveMinimum json.RawMessage `json:"exclusiveMinimum,omitempty"`
+		ExclusiveMaximum json.RawMessage `json:"exclusiveMaximum,omitempty"`
+	}{schema: (*schema)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if s.ExclusiveMinimum, err = exclusiveBound(aux.ExclusiveMinimum, &s.Minimum); err != nil {
+		return err
+	}
+	s.ExclusiveMaximum, err = exclusiveBound(aux.ExclusiveMaximum, &s.Maximum)
+	return err
+}
+
+// exclusiveBound 解析 exclusiveMinimum 或 exclusiveMaximum，布尔形式为 true 时将 bound 转换为不包含边界
+func exclusiveBound(raw json.RawMessage, bound **float64) (*float64, error) {
+	switch string(bytes.TrimSpace(raw)) {
+	case "", "false", "null":
+		return nil, nil
+	case "true":
+		res := *bound
+		*bound = nil
+		return res, nil
+	}
+	var res float64
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// FieldFilter 返回 false 的结构体字段不会出现在 Schema 中
+type FieldFilter func(f reflect.StructField) bool
+
+// Reflector 根据 Go 类型生成 Schema，具名结构体放入 Components 并通过 $ref 引用
+//
+// 结构体字段支持以下标签：
+//   - json: 字段名，- 表示忽略，带 omitempty 的字段不是必填的
+//   - required: "true" 或 "false"，覆盖根据 json 标签得出的是否必填
+//   - description、format、pattern、example、default: 对应的 Schema 属性
+//   - enum: 逗号分隔的枚举值
+//   - minimum、maximum、minLength、maxLength: 对应的 Schema 属性
+type Reflector struct {
+	Components *Components
+	names      map[reflect.Type]string // 已经放入 Components 的类型
+}
+
+// NewReflector 创建 Reflector，生成的组件放入 components
+func NewReflector(components *Components) *Reflector {
+	if components.Schemas == nil {
+		components.Schemas = make(map[string]*Schema)
+	}
+	return &Reflector{Components: components, names: make(map[reflect.Type]string)}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	invalidNameChar   = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// StructSchema 返回结构体 t 的内联 Schema，只包含 filter 返回 true 的字段
+func (r *Reflector) StructSchema(t reflect.Type, filter FieldFilter) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return r.Schema(t)
+	}
+	return r.structSchema(t, filter)
+}
+
+// Schema 返回类型 t 的 Schema
+func (r *Reflector) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: Types{TypeString}, Format: "date-time"}
+	case durationType:
+		return &Schema{Type: Types{TypeInteger}, Format: "int64", Description: "duration in nanoseconds"}
+	case rawMessageType:
+		return &Schema{}
+	}
+	if reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		// 自定义 JSON 编码的类型无法推断结构
+		return &Schema{}
+	}
+	if reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: Types{TypeString}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: Types{TypeBoolean}}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: Types{TypeInteger}, Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: Types{TypeInteger}, Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: Types{TypeInteger}, Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: Types{TypeNumber}, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: Types{TypeNumber}, Format: "double"}
+	case reflect.String:
+		return &Schema{Type: Types{TypeString}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: Types{TypeString}, Format: "byte"}
+		}
+		return &Schema{Type: Types{TypeArray}, Items: r.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: Types{TypeObject}, AdditionalProperties: r.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t, nil)
+		}
+		return r.ref(t)
+	}
+	// interface 等任意类型
+	return &Schema{}
+}
+
+// FieldSchema 返回结构体字段的 Schema，并根据字段标签设置属性
+func (r *Reflector) FieldSchema(f reflect.StructField) *Schema {
+	res := r.Schema(f.Type)
+	applyTags(res, f.Tag)
+	return res
+}
+
+// ref 将具名结构体放入 Components，返回引用
+func (r *Reflector) ref(t reflect.Type) *Schema {
+	name, ok := r.names[t]
+	if !ok {
+		name = r.componentName(t)
+		r.names[t] = name
+		// 先占位，支持递归引用
+		r.Components.Schemas[name] = &Schema{}
+		*r.Components.Schemas[name] = *r.structSchema(t, nil)
+	}
+	return &Schema{Ref: componentPrefix + "schemas/" + name}
+}
+
+// componentName 生成组件名，不同包中的同名类型使用包名区分
+func (r *Reflector) componentName(t reflect.Type) string {
+	name := invalidNameChar.ReplaceAllString(t.Name(), "_")
+	name = strings.Trim(name, "_")
+	if _, exists := r.Components.Schemas[name]; !exists {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	base := invalidNameChar.ReplaceAllString(pkg, "_") + "." + name
+	res := base
+	for i := 2; ; i++ {
+		if _, exists := r.Components.Schemas[res]; !exists {
+			return res
+		}
+		res = base + strconv.Itoa(i)
+	}
+}
+
+// structSchema 生成结构体的 Schema，展开嵌入的结构体
+func (r *Reflector) structSchema(t reflect.Type, filter FieldFilter) *Schema {
+	res := &Schema{Type: Types{TypeObject}, Properties: make(map[string]*Schema)}
+	r.addFields(res, t, filter)
+	if len(res.Properties) == 0 {
+		res.Properties = nil
+	}
+	return res
+}
+
+// addFields 将结构体字段添加到 Schema
+func (r *Reflector) addFields(res *Schema, t reflect.Type, filter FieldFilter) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if filter != nil && !filter(f) {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			r.addFields(res, ft, filter)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		res.Properties[name] = r.FieldSchema(f)
+
+		required := !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer
+		if val, ok := f.Tag.Lookup("required"); ok {
+			required, _ = strconv.ParseBool(val)
+		}
+		if required {
+			res.Required = append(res.Required, name)
+		}
+	}
+}
+
+// applyTags 根据字段标签设置 Schema 的属性，引用只设置描述，其他属性属于被引用的组件
+func applyTags(s *Schema, tag reflect.StructTag) {
+	// 3.1 允许 $ref 与其他关键字并列
+	if desc := tag.Get("description"); desc != "" {
+		s.Description = desc
+	}
+	if s.Ref != "" {
+		return
+	}
+	if format := tag.Get("format"); format != "" {
+		s.Format = format
+	}
+	if pattern := tag.Get("pattern"); pattern != "" {
+		s.Pattern = pattern
+	}
+	if val, ok := tag.Lookup("example"); ok {
+		s.Example = tagValue(s, val)
+	}
+	if val, ok := tag.Lookup("default"); ok {
+		s.Default = tagValue(s, val)
+	}
+	if val := tag.Get("enum"); val != "" {
+		for _, v := range strings.Split(val, ",") {
+			s.Enum = append(s.Enum, tagValue(s, strings.TrimSpace(v)))
+		}
+	}
+	s.Minimum = tagFloat(tag, "minimum", s.Minimum)
+	s.Maximum = tagFloat(tag, "maximum", s.Maximum)
+	s.MinLength = tagInt(tag, "minLength", s.MinLength)
+	s.MaxLength = tagInt(tag, "maxLength", s.MaxLength)
+}
+
+// tagValue 将标签中的字符串按 Schema 的类型转换
+func tagValue(s *Schema, raw string) any {
+	switch {
+	case s.Type.Has(TypeInteger):
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return v
+		}
+	case s.Type.Has(TypeNumber):
+		if v, err := strconv.ParseFloat(raw, 64); err == nil {
+			return v
+		}
+	case s.Type.Has(TypeBoolean):
+		if v, err := strconv.ParseBool(raw); err == nil {
+			return v
+		}
+	case s.Type.Has(TypeArray), s.Type.Has(TypeObject):
+		var v any
+		if err := json.Unmarshal([]byte(raw), &v); err == nil {
+			return v
+		}
+	}
+	return raw
+}
+
+// tagFloat 解析数字标签，没有或无法解析时返回 def
+func tagFloat(tag reflect.StructTag, key string, def *float64) *float64 {
+	if v, err := strconv.ParseFloat(tag.Get(key), 64); err == nil {
+		return &v
+	}
+	return def
+}
+
+// tagInt 解析整数标签，没有或无法解析时返回 def
+func tagInt(tag reflect.StructTag, key string, def *int) *int {
+	if v, err := strconv.Atoi(tag.Get(key)); err == nil {
+		return &v
+	}
+	return def
+}
@@ -0,0 +1,145 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaAddress struct {
+	City string `json:"city" description:"city name" example:"Shanghai"`
+}
+
+type schemaUser struct {
+	ID        int64            `json:"id"`
+	Name      string           `json:"name" minLength:"1" maxLength:"32"`
+	Email     string           `json:"email,omitempty" format:"email"`
+	Age       uint8            `json:"age" maximum:"150"`
+	Role      string           `json:"role" enum:"admin, user" default:"user"`
+	Score     float64          `json:"score" example:"9.5"`
+	Tags      []string         `json:"tags"`
+	Attrs     map[string]int   `json:"attrs"`
+	Avatar    []byte           `json:"avatar"`
+	Address   *schemaAddress   `json:"address" description:"home address"`
+	Friends   []schemaUser     `json:"friends"`
+	CreatedAt time.Time        `json:"created_at"`
+	IP        net.IP           `json:"ip"`
+	Raw       json.RawMessage  `json:"raw"`
+	Any       any              `json:"any"`
+	Nested    struct{ X bool } `json:"nested" required:"false"`
+	Ignored   string           `json:"-"`
+	Optional  *int             `json:"optional"`
+	Forced    string           `json:"forced,omitempty" required:"true"`
+	schemaEmbedded
+	private string
+}
+
+type schemaEmbedded struct {
+	Version int `json:"version"`
+}
+
+func TestReflector_Schema(t *testing.T) {
+	components := &Components{}
+	r := NewReflector(components)
+	res := r.Schema(reflect.TypeOf(&schemaUser{}))
+	assert.Equal(t, "#/components/schemas/schemaUser", res.Ref)
+
+	user := components.Schemas["schemaUser"]
+	require.NotNil(t, user)
+	data, err := json.Marshal(user)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "format": "int64"},
+			"name": {"type": "string", "minLength": 1, "maxLength": 32},
+			"email": {"type": "string", "format": "email"},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"role": {"type": "string", "enum": ["admin", "user"], "default": "user"},
+			"score": {"type": "number", "format": "double", "example": 9.5},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"attrs": {"type": "object", "additionalProperties": {"type": "integer", "format": "int64"}},
+			"avatar": {"type": "string", "format": "byte"},
+			"address": {"$ref": "#/components/schemas/schemaAddress", "description": "home address"},
+			"friends": {"type": "array", "items": {"$ref": "#/components/schemas/schemaUser"}},
+			"created_at": {"type": "string", "format": "date-time"},
+			"ip": {"type": "string"},
+			"raw": {},
+			"any": {},
+			"nested": {"type": "object", "properties": {"X": {"type": "boolean"}}, "required": ["X"]},
+			"optional": {"type": "integer", "format": "int64"},
+			"forced": {"type": "string"},
+			"version": {"type": "integer", "format": "int64"}
+		},
+		"required": ["id", "name", "age", "role", "score", "tags", "attrs", "avatar", "friends", "created_at", "ip", "raw", "any", "forced", "version"]
+	}`, string(data))
+
+	address, err := json.Marshal(components.Schemas["schemaAddress"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","properties":{"city":{"type":"string","description":"city name","example":"Shanghai"}},"required":["city"]}`, string(address))
+}
+
+func TestReflector_StructSchema(t *testing.T) {
+	components := &Components{}
+	r := NewReflector(components)
+	res := r.StructSchema(reflect.TypeOf(schemaAddress{}), func(f reflect.StructField) bool {
+		return f.Name != "City"
+	})
+	assert.Equal(t, &Schema{Type: Types{TypeObject}}, res)
+	assert.Empty(t, components.Schemas)
+
+	assert.Equal(t, &Schema{Type: Types{TypeString}}, r.StructSchema(reflect.TypeOf(""), nil))
+}
+
+func TestReflector_ComponentName(t *testing.T) {
+	type schemaAddress struct {
+		Street string `json:"street"`
+	}
+	type page[T any] struct {
+		Items []T `json:"items"`
+	}
+	components := &Components{}
+	r := NewReflector(components)
+	assert.Equal(t, "#/components/schemas/schemaAddress", r.Schema(reflect.TypeOf(schemaAddress{})).Ref)
+	assert.Equal(t, "#/components/schemas/openapi.schemaAddress", r.Schema(reflect.TypeOf(schemaAddress2{})).Ref)
+	ref := r.Schema(reflect.TypeOf(page[int]{})).Ref
+	assert.Regexp(t, `^#/components/schemas/[a-zA-Z0-9._-]+$`, ref)
+	// 同一个类型只生成一次
+	assert.Equal(t, "#/components/schemas/schemaAddress", r.Schema(reflect.TypeOf(schemaAddress{})).Ref)
+}
+
+// schemaAddress2 用于在函数内引用与局部类型同名的 schemaAddress
+type schemaAddress2 = schemaAddress
+
+func TestSchema_JSON(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema *Schema
+		json   string
+	}{
+		{name: "single type", schema: &Schema{Type: Types{TypeString}}, json: `{"type":"string"}`},
+		{name: "multiple types", schema: &Schema{Type: Types{TypeString, TypeNull}}, json: `{"type":["string","null"]}`},
+		{name: "false", schema: False(), json: `false`},
+		{name: "true", schema: &Schema{}, json: `{}`},
+		{name: "nested false", schema: &Schema{Type: Types{TypeObject}, AdditionalProperties: False()}, json: `{"type":"object","additionalProperties":false}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.schema)
+			require.NoError(t, err)
+			assert.Equal(t, tc.json, string(data))
+
+			var res Schema
+			require.NoError(t, json.Unmarshal(data, &res))
+			assert.Equal(t, tc.schema, &res)
+		})
+	}
+	var res Schema
+	require.NoError(t, json.Unmarshal([]byte(`true`), &res))
+	assert.Equal(t, Schema{}, res)
+}
@@ -0,0 +1,501 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxValidateDepth 校验嵌套的最大深度，防止递归引用导致无限循环
+const maxValidateDepth = 64
+
+// SchemaError 值不符合 Schema 的错误
+type SchemaError struct {
+	Path    string // 出错位置的 JSON Pointer，例如 /items/0/name，根为空字符串
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// SchemaErrors 多个校验错误
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validator 一次校验的状态
+type validator struct {
+	doc  *Document
+	errs SchemaErrors
+}
+
+// Validate 校验 val 是否符合 Schema，val 为 encoding/json 解析得到的值，数字可以是 json.Number
+// 不符合时返回 SchemaErrors
+func (d *Document) Validate(s *Schema, val any) error {
+	v := &validator{doc: d}
+	v.validate(s, val, "", 0)
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// addf 记录错误
+func (v *validator) addf(path string, format string, args ...any) {
+	v.errs = append(v.errs, &SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// passes 判断值是否符合 Schema，不记录错误
+func (v *validator) passes(s *Schema, val any, depth int) bool {
+	sub := &validator{doc: v.doc}
+	sub.validate(s, val, "", depth)
+	return len(sub.errs) == 0
+}
+
+// validate 校验值，错误记录在 v.errs 中
+func (v *validator) validate(s *Schema, val any, path string, depth int) {
+	if s == nil {
+		return
+	}
+	if depth > maxValidateDepth {
+		v.addf(path, "schema nesting too deep")
+		return
+	}
+	s, err := v.doc.ResolveSchema(s)
+	if err != nil {
+		v.addf(path, "%v", err)
+		return
+	}
+	if s.isFalse() {
+		v.addf(path, "value is not allowed")
+		return
+	}
+
+	actual := jsonType(val)
+	if !v.validateType(s, val, actual, path) {
+		return
+	}
+	if len(s.Enum) > 0 && !containsValue(s.Enum, val) {
+		v.addf(path, "value must be one of %s", formatValues(s.Enum))
+	}
+	if s.Const != nil && !equalValue(s.Const, val) {
+		v.addf(path, "value must be %s", formatValue(s.Const))
+	}
+	if actual == TypeNull {
+		// nullable: true 常与 allOf 引用不可为空的模型一起使用，null 不再校验组合关键字
+		return
+	}
+
+	switch actual {
+	case TypeString:
+		v.validateString(s, val.(string), path)
+	case TypeNumber, TypeInteger:
+		v.validateNumber(s, val, path)
+	case TypeArray:
+		v.validateArray(s, val.([]any), path, depth)
+	case TypeObject:
+		v.validateObject(s, val.(map[string]any), path, depth)
+	}
+
+	for _, sub := range s.AllOf {
+		v.validate(sub, val, path, depth+1)
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			if v.passes(sub, val, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.addf(path, "value must match at least one schema in anyOf")
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if v.passes(sub, val, depth+1) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.addf(path, "value must match exactly one schema in oneOf, matched %d", matched)
+		}
+	}
+	if s.Not != nil && v.passes(s.Not, val, depth+1) {
+		v.addf(path, "value must not match the schema in not")
+	}
+}
+
+// validateType 校验类型，类型不符时返回 false，不再校验其他关键字
+func (v *validator) validateType(s *Schema, val any, actual string, path string) bool {
+	if actual == TypeNull {
+		if len(s.Type) == 0 || s.Type.Has(TypeNull) || s.Nullable {
+			return true
+		}
+		v.addf(path, "expected %s, got null", strings.Join(s.Type, " or "))
+		return false
+	}
+	if len(s.Type) == 0 || s.Type.Has(actual) {
+		return true
+	}
+	if actual == TypeInteger && s.Type.Has(TypeNumber) {
+		return true
+	}
+	if actual == "" {
+		v.addf(path, "unsupported value %T", val)
+		return false
+	}
+	v.addf(path, "expected %s, got %s", strings.Join(s.Type, " or "), actual)
+	return false
+}
+
+// jsonType 返回值的 JSON 类型，整数返回 integer
+func jsonType(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case string:
+		return TypeString
+	case []any:
+		return TypeArray
+	case map[string]any:
+		return TypeObject
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return TypeInteger
+		}
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return TypeInteger
+		}
+		return TypeNumber
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return TypeInteger
+	case float32, float64:
+		f, _ := toFloat(v)
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return TypeInteger
+		}
+		return TypeNumber
+	}
+	return ""
+}
+
+// toFloat 将数字转换为 float64
+func toFloat(val any) (float64, bool) {
+	switch v := val.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// normalize 将数字统一为 float64，用于比较
+func normalize(val any) any {
+	if f, ok := toFloat(val); ok {
+		return f
+	}
+	switch v := val.(type) {
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = normalize(item)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[k] = normalize(item)
+		}
+		return res
+	}
+	return val
+}
+
+// equalValue 判断两个 JSON 值是否相等
+func equalValue(a, b any) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// containsValue 判断 list 中是否有与 val 相等的值
+func containsValue(list []any, val any) bool {
+	for _, item := range list {
+		if equalValue(item, val) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue 将值格式化为 JSON
+func formatValue(val any) string {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(data)
+}
+
+// formatValues 将多个值格式化为 JSON
+func formatValues(vals []any) string {
+	res := make([]string, len(vals))
+	for i, val := range vals {
+		res[i] = formatValue(val)
+	}
+	return strings.Join(res, ", ")
+}
+
+// patternCache pattern -> *regexp.Regexp
+var patternCache sync.Map
+
+// compilePattern 编译并缓存正则表达式
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// validateString 校验字符串
+func (v *validator) validateString(s *Schema, val string, path string) {
+	n := utf8.RuneCountInString(val)
+	if s.MinLength != nil && n < *s.MinLength {
+		v.addf(path, "length must be at least %d", *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		v.addf(path, "length must be at most %d", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		re, err := compilePattern(s.Pattern)
+		if err != nil {
+			v.addf(path, "invalid pattern %q: %v", s.Pattern, err)
+		} else if !re.MatchString(val) {
+			v.addf(path, "value must match pattern %q", s.Pattern)
+		}
+	}
+	if s.Format != "" && !validFormat(s.Format, val) {
+		v.addf(path, "value is not a valid %s", s.Format)
+	}
+}
+
+// uuidPattern UUID 的格式
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validFormat 校验常见的字符串格式，不认识的格式视为合法
+func validFormat(format, val string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, val)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, val)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", val)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(val)
+		return err == nil && addr.Address == val
+	case "uuid":
+		return uuidPattern.MatchString(val)
+	case "uri":
+		u, err := url.Parse(val)
+		return err == nil && u.IsAbs()
+	case "uri-reference":
+		_, err := url.Parse(val)
+		return err == nil
+	case "ipv4":
+		ip := net.ParseIP(val)
+		return ip != nil && ip.To4() != nil && !strings.Contains(val, ":")
+	case "ipv6":
+		ip := net.ParseIP(val)
+		return ip != nil && strings.Contains(val, ":")
+	case "byte":
+		return base64Pattern.MatchString(val)
+	}
+	return true
+}
+
+// base64Pattern 标准 base64 编码
+var base64Pattern = regexp.MustCompile(`^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{2}==|[A-Za-z0-9+/]{3}=)?$`)
+
+// validateNumber 校验数字
+func (v *validator) validateNumber(s *Schema, val any, path string) {
+	f, ok := toFloat(val)
+	if !ok {
+		v.addf(path, "invalid number %v", val)
+		return
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		v.addf(path, "value must be >= %v", *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		v.addf(path, "value must be <= %v", *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+		v.addf(path, "value must be > %v", *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+		v.addf(path, "value must be < %v", *s.ExclusiveMaximum)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		q := f / *s.MultipleOf
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			v.addf(path, "value must be a multiple of %v", *s.MultipleOf)
+		}
+	}
+}
+
+// validateArray 校验数组
+func (v *validator) validateArray(s *Schema, val []any, path string, depth int) {
+	if s.MinItems != nil && len(val) < *s.MinItems {
+		v.addf(path, "must have at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(val) > *s.MaxItems {
+		v.addf(path, "must have at most %d items", *s.MaxItems)
+	}
+	if s.UniqueItems {
+		for i := 1; i < len(val); i++ {
+			if containsValue(val[:i], val[i]) {
+				v.addf(path, "items must be unique, item %d is a duplicate", i)
+				break
+			}
+		}
+	}
+	if s.Items != nil {
+		for i, item := range val {
+			v.validate(s.Items, item, path+"/"+strconv.Itoa(i), depth+1)
+		}
+	}
+}
+
+// escapePointer 转义 JSON Pointer 中的 ~ 和 /
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// validateObject 校验对象
+func (v *validator) validateObject(s *Schema, val map[string]any, path string, depth int) {
+	for _, name := range s.Required {
+		if _, ok := val[name]; !ok {
+			v.addf(path+"/"+escapePointer(name), "property is required")
+		}
+	}
+	if s.MinProperties != nil && len(val) < *s.MinProperties {
+		v.addf(path, "must have at least %d properties", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && len(val) > *s.MaxProperties {
+		v.addf(path, "must have at most %d properties", *s.MaxProperties)
+	}
+	keys := make([]string, 0, len(val))
+	for k := range val {
+		keys = append(keys, k)
+	}
+	// 按固定顺序输出错误
+	sort.Strings(keys)
+	for _, k := range keys {
+		propPath := path + "/" + escapePointer(k)
+		if prop, ok := s.Properties[k]; ok {
+			v.validate(prop, val[k], propPath, depth+1)
+			continue
+		}
+		if s.AdditionalProperties != nil {
+			if s.AdditionalProperties.isFalse() {
+				v.addf(propPath, "additional property is not allowed")
+				continue
+			}
+			v.validate(s.AdditionalProperties, val[k], propPath, depth+1)
+		}
+	}
+}
+
+// Coerce 将字符串形式的参数值按照 Schema 的类型转换，用于校验路径、查询参数和请求头
+// 数组类型使用所有值，只有一个值时按逗号分隔；其他类型只使用第一个值
+func (d *Document) Coerce(s *Schema, vals []string) (any, error) {
+	s, err := d.ResolveSchema(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	if s == nil {
+		return vals[0], nil
+	}
+	if s.Type.Has(TypeArray) {
+		if len(vals) == 1 && strings.Contains(vals[0], ",") {
+			vals = strings.Split(vals[0], ",")
+		}
+		res := make([]any, len(vals))
+		for i, val := range vals {
+			if res[i], err = d.Coerce(s.Items, []string{val}); err != nil {
+				return nil, &SchemaError{Path: "/" + strconv.Itoa(i), Message: err.Error()}
+			}
+		}
+		return res, nil
+	}
+	raw := vals[0]
+	for _, typ := range s.Type {
+		switch typ {
+		case TypeInteger:
+			if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return i, nil
+			}
+		case TypeNumber:
+			if f, err := strconv.ParseFloat(raw, 64); err == nil {
+				return f, nil
+			}
+		case TypeBoolean:
+			if b, err := strconv.ParseBool(raw); err == nil {
+				return b, nil
+			}
+		case TypeString:
+			return raw, nil
+		}
+	}
+	if len(s.Type) == 0 || s.Type.Has(TypeObject) {
+		return raw, nil
+	}
+	return nil, &SchemaError{Message: fmt.Sprintf("expected %s, got %q", strings.Join(s.Type, " or "), raw)}
+}
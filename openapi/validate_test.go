@@ -0,0 +1,215 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validateSpec = `
+openapi: 3.0.3
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      additionalProperties: false
+      properties:
+        id: {type: integer, minimum: 1}
+        name: {type: string, minLength: 1, maxLength: 8}
+        kind: {type: string, enum: [cat, dog]}
+        email: {type: string, format: email}
+        tags:
+          type: array
+          items: {type: string}
+          uniqueItems: true
+          maxItems: 2
+        weight: {type: number, minimum: 0, exclusiveMinimum: true, multipleOf: 0.5}
+        owner: {type: string, nullable: true}
+`
+
+func decodeValue(t *testing.T, data string) any {
+	var res any
+	require.NoError(t, json.Unmarshal([]byte(data), &res))
+	return res
+}
+
+func TestDocument_Validate(t *testing.T) {
+	doc, err := Parse([]byte(validateSpec))
+	require.NoError(t, err)
+	pet := &Schema{Ref: "#/components/schemas/Pet"}
+
+	testCases := []struct {
+		name    string
+		schema  *Schema
+		val     string
+		wantErr []string
+	}{
+		{
+			name:   "valid",
+			schema: pet,
+			val:    `{"id": 1, "name": "tom", "kind": "cat", "tags": ["a", "b"], "weight": 1.5, "owner": null}`,
+		},
+		{
+			name:    "missing required",
+			schema:  pet,
+			val:     `{"id": 1}`,
+			wantErr: []string{`/name: property is required`},
+		},
+		{
+			name:   "multiple errors",
+			schema: pet,
+			val:    `{"id": 0, "name": "", "kind": "bird", "extra": true}`,
+			wantErr: []string{
+				`/extra: additional property is not allowed`,
+				`/id: value must be >= 1`,
+				`/kind: value must be one of "cat", "dog"`,
+				`/name: length must be at least 1`,
+			},
+		},
+		{
+			name:    "integer type",
+			schema:  pet,
+			val:     `{"id": 1.5, "name": "tom"}`,
+			wantErr: []string{`/id: expected integer, got number`},
+		},
+		{
+			name:    "format",
+			schema:  pet,
+			val:     `{"name": "tom", "email": "tom"}`,
+			wantErr: []string{`/email: value is not a valid email`},
+		},
+		{
+			name:   "array",
+			schema: pet,
+			val:    `{"name": "tom", "tags": ["a", "a", "b"]}`,
+			wantErr: []string{
+				`/tags: must have at most 2 items`,
+				`/tags: items must be unique, item 1 is a duplicate`,
+			},
+		},
+		{
+			name:    "exclusive minimum from 3.0",
+			schema:  pet,
+			val:     `{"name": "tom", "weight": 0}`,
+			wantErr: []string{`/weight: value must be > 0`},
+		},
+		{
+			name:    "multiple of",
+			schema:  pet,
+			val:     `{"name": "tom", "weight": 1.2}`,
+			wantErr: []string{`/weight: value must be a multiple of 0.5`},
+		},
+		{
+			name:    "not nullable",
+			schema:  pet,
+			val:     `{"name": null}`,
+			wantErr: []string{`/name: expected string, got null`},
+		},
+		{
+			name:    "nullable enum",
+			schema:  &Schema{Type: Types{TypeString}, Nullable: true, Enum: []any{"cat", "dog"}},
+			val:     `null`,
+			wantErr: []string{`value must be one of "cat", "dog"`},
+		},
+		{
+			name:   "nullable enum with null",
+			schema: &Schema{Type: Types{TypeString}, Nullable: true, Enum: []any{"cat", nil}},
+			val:    `null`,
+		},
+		{
+			name:   "nullable ref",
+			schema: &Schema{Nullable: true, AllOf: []*Schema{pet}},
+			val:    `null`,
+		},
+		{
+			name:   "one of",
+			schema: &Schema{OneOf: []*Schema{{Type: Types{TypeString}}, {Type: Types{TypeInteger}}}},
+			val:    `"a"`,
+		},
+		{
+			name:    "any of",
+			schema:  &Schema{AnyOf: []*Schema{{Type: Types{TypeString}}, {Type: Types{TypeInteger}}}},
+			val:     `true`,
+			wantErr: []string{`value must match at least one schema in anyOf`},
+		},
+		{
+			name:    "not",
+			schema:  &Schema{Not: &Schema{Type: Types{TypeString}}},
+			val:     `"a"`,
+			wantErr: []string{`value must not match the schema in not`},
+		},
+		{
+			name:    "false schema",
+			schema:  False(),
+			val:     `1`,
+			wantErr: []string{`value is not allowed`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := doc.Validate(tc.schema, decodeValue(t, tc.val))
+			if len(tc.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var errs SchemaErrors
+			require.ErrorAs(t, err, &errs)
+			msgs := make([]string, len(errs))
+			for i, e := range errs {
+				msgs[i] = e.Error()
+			}
+			assert.ElementsMatch(t, tc.wantErr, msgs)
+		})
+	}
+}
+
+func TestDocument_Coerce(t *testing.T) {
+	doc := &Document{}
+	testCases := []struct {
+		name    string
+		schema  *Schema
+		vals    []string
+		want    any
+		wantErr string
+	}{
+		{name: "no schema", vals: []string{"a"}, want: "a"},
+		{name: "integer", schema: &Schema{Type: Types{TypeInteger}}, vals: []string{"12"}, want: int64(12)},
+		{name: "number", schema: &Schema{Type: Types{TypeNumber}}, vals: []string{"1.5"}, want: 1.5},
+		{name: "boolean", schema: &Schema{Type: Types{TypeBoolean}}, vals: []string{"true"}, want: true},
+		{name: "nullable integer", schema: &Schema{Type: Types{TypeInteger, TypeString}}, vals: []string{"x"}, want: "x"},
+		{
+			name:   "array of values",
+			schema: &Schema{Type: Types{TypeArray}, Items: &Schema{Type: Types{TypeInteger}}},
+			vals:   []string{"1", "2"},
+			want:   []any{int64(1), int64(2)},
+		},
+		{
+			name:   "comma separated array",
+			schema: &Schema{Type: Types{TypeArray}, Items: &Schema{Type: Types{TypeString}}},
+			vals:   []string{"a,b"},
+			want:   []any{"a", "b"},
+		},
+		{
+			name:    "invalid integer",
+			schema:  &Schema{Type: Types{TypeInteger}},
+			vals:    []string{"abc"},
+			wantErr: `expected integer, got "abc"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := doc.Coerce(tc.schema, tc.vals)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Andras5014/go-web/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type docUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name" description:"user name"`
+}
+
+type docCreateUser struct {
+	Name  string `json:"name"`
+	Trace string `json:"-" header:"X-Trace-Id" description:"trace id"`
+}
+
+type docListUsers struct {
+	Page int    `query:"page" default:"1" description:"page number"`
+	Q    string `query:"q" required:"true"`
+}
+
+type docGetUser struct {
+	ID int64 `path:"id"`
+}
+
+type docUpload struct {
+	Name string `form:"name"`
+}
+
+type docCreated docUser
+
+func (docCreated) StatusCode() int {
+	return http.StatusCreated
+}
+
+func newDocEngine(opts ...EngineOption) *Engine {
+	e := NewEngine(opts...)
+	users := e.Group("/users")
+	users.Use(Doc(DocTags("users"), DocSecurity("bearer")))
+	users.GET("", Typed(func(ctx *Context, req docListUsers) ([]docUser, error) {
+		return nil, nil
+	}))
+	users.POST("", Doc(DocSummary("Create user"), DocOperationID("createUser")),
+		Typed(func(ctx *Context, req docCreateUser) (docCreated, error) {
+			return docCreated{}, nil
+		}))
+	users.GET("/:id", Doc(DocSummary("Get user"), DocResponse(http.StatusNotFound, "user not found", nil)),
+		Typed(func(ctx *Context, req docGetUser) (*docUser, error) {
+			return nil, nil
+		}))
+	users.DELETE("/:id", Doc(DocDeprecated()), func(ctx *Context) {})
+	e.POST("/upload", Typed(func(ctx *Context, req docUpload) (any, error) {
+		return nil, nil
+	}))
+	e.GET("/static/*", Doc(DocResponse(http.StatusOK, "file", "")), func(ctx *Context) {})
+	e.GET("/internal", Doc(DocHidden()), func(ctx *Context) {})
+	return e
+}
+
+func TestEngine_OpenAPI(t *testing.T) {
+	e := newDocEngine(WithProblemDetails())
+	doc := e.OpenAPI(
+		WithOpenAPIInfo(openapi.Info{Title: "Users", Version: "2.0.0"}),
+		WithOpenAPIServers(openapi.Server{URL: "https://api.example.com"}),
+		WithSecurityScheme("bearer", &openapi.SecurityScheme{Type: "http", Scheme: "bearer"}),
+	)
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+	assert.Equal(t, "Users", doc.Info.Title)
+	assert.Equal(t, "https://api.example.com", doc.Servers[0].URL)
+	assert.Equal(t, []openapi.Tag{{Name: "users"}}, doc.Tags)
+	assert.Equal(t, "bearer", doc.Components.SecuritySchemes["bearer"].Scheme)
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	assert.ElementsMatch(t, []string{"/users", "/users/{id}", "/upload", "/static/{*}"}, paths)
+
+	list := doc.Paths["/users"].Get
+	require.NotNil(t, list)
+	assert.Equal(t, []string{"users"}, list.Tags)
+	assert.Equal(t, []openapi.SecurityRequirement{{"bearer": {}}}, list.Security)
+	assert.Nil(t, list.RequestBody)
+	require.Len(t, list.Parameters, 2)
+	assert.Equal(t, &openapi.Parameter{
+		Name: "page", In: openapi.InQuery, Description: "page number",
+		Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeInteger}, Format: "int64", Default: int64(1)},
+	}, list.Parameters[0])
+	assert.True(t, list.Parameters[1].Required)
+	assert.Equal(t, &openapi.Schema{
+		Type:  openapi.Types{openapi.TypeArray},
+		Items: &openapi.Schema{Ref: "#/components/schemas/docUser"},
+	}, list.Responses["200"].Content[MIMEJSON].Schema)
+	assert.Equal(t, "#/components/schemas/Problem", list.Responses["default"].Content[ProblemContentType].Schema.Ref)
+
+	create := doc.Paths["/users"].Post
+	require.NotNil(t, create)
+	assert.Equal(t, "Create user", create.Summary)
+	assert.Equal(t, "createUser", create.OperationID)
+	assert.Equal(t, "X-Trace-Id", create.Parameters[0].Name)
+	assert.Equal(t, openapi.InHeader, create.Parameters[0].In)
+	body := create.RequestBody.Content[MIMEJSON].Schema
+	assert.Equal(t, []string{"name"}, body.Required)
+	assert.Len(t, body.Properties, 1)
+	assert.Contains(t, create.Responses, "201")
+	assert.Equal(t, "#/components/schemas/docCreated", create.Responses["201"].Content[MIMEJSON].Schema.Ref)
+
+	get := doc.Paths["/users/{id}"].Get
+	require.NotNil(t, get)
+	assert.Equal(t, &openapi.Parameter{
+		Name: "id", In: openapi.InPath, Required: true,
+		Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeInteger}, Format: "int64"},
+	}, get.Parameters[0])
+	assert.Equal(t, "user not found", get.Responses["404"].Description)
+	assert.Nil(t, get.Responses["404"].Content)
+	assert.Equal(t, "#/components/schemas/docUser", get.Responses["200"].Content[MIMEJSON].Schema.Ref)
+
+	del := doc.Paths["/users/{id}"].Delete
+	require.NotNil(t, del)
+	assert.True(t, del.Deprecated)
+	assert.Equal(t, "id", del.Parameters[0].Name)
+	assert.Equal(t, "OK", del.Responses["200"].Description)
+
+	upload := doc.Paths["/upload"].Post
+	require.NotNil(t, upload)
+	assert.NotContains(t, upload.RequestBody.Content, MIMEJSON)
+	assert.Contains(t, upload.RequestBody.Content["multipart/form-data"].Schema.Properties, "name")
+	assert.Equal(t, &openapi.Schema{}, upload.Responses["200"].Content[MIMEJSON].Schema)
+
+	static := doc.Paths["/static/{*}"].Get
+	assert.Equal(t, "*", static.Parameters[0].Name)
+	assert.Equal(t, openapi.Types{openapi.TypeString}, static.Responses["200"].Content[MIMEJSON].Schema.Type)
+
+	assert.Contains(t, doc.Components.Schemas, "docUser")
+	assert.Contains(t, doc.Components.Schemas, "Problem")
+}
+
+func TestEngine_OpenAPI_Default(t *testing.T) {
+	e := NewEngine()
+	e.GET("/items", Typed(func(ctx *Context, req struct{}) (map[string]int, error) {
+		return nil, nil
+	}))
+	doc := e.OpenAPI()
+	assert.Equal(t, openapi.Info{Title: "API", Version: "1.0.0"}, doc.Info)
+	assert.Nil(t, doc.Components)
+	op := doc.Paths["/items"].Get
+	assert.Empty(t, op.Parameters)
+	assert.Equal(t, MIMEText, func() string {
+		for k := range op.Responses["default"].Content {
+			return k
+		}
+		return ""
+	}())
+}
+
+func TestEngine_ServeOpenAPI(t *testing.T) {
+	e := newDocEngine()
+	e.ServeOpenAPI(WithOpenAPIPath("/api/openapi.json"), WithDocsUI("/docs"),
+		WithOpenAPIInfo(openapi.Info{Title: "Users <API>", Version: "1"}))
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, MIMEJSON, recorder.Header().Get("Content-Type"))
+	doc, err := openapi.Parse(recorder.Body.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "Users <API>", doc.Info.Title)
+	assert.NotContains(t, doc.Paths, "/api/openapi.json")
+	assert.NotContains(t, doc.Paths, "/docs")
+	assert.NotContains(t, doc.Paths, "/internal")
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "<title>Users &lt;API&gt;</title>")
+	assert.Contains(t, recorder.Body.String(), `var specURL = "/api/openapi.json";`)
+}
+
+func TestDoc_Middleware(t *testing.T) {
+	e := NewEngine()
+	e.GET("/ping", Doc(DocSummary("ping")), func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "pong")
+	})
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.Equal(t, "pong", recorder.Body.String())
+
+	// 每次调用 Doc 都是不同的处理函数，其他处理函数在收集文档时不会被调用
+	h1, h2 := Doc(DocSummary("a")), Doc(DocSummary("b"))
+	assert.Equal(t, "a", describeRoute([]HandleFunc{h1}).route.Summary)
+	assert.Equal(t, "b", describeRoute([]HandleFunc{h2}).route.Summary)
+	doc := describeRoute([]HandleFunc{func(ctx *Context) {
+		t.Fatal("handler called while describing")
+	}, h1, Typed(func(ctx *Context, req docGetUser) (*docUser, error) {
+		return nil, nil
+	})})
+	assert.Equal(t, "a", doc.route.Summary)
+	assert.Equal(t, reflect.TypeOf(docGetUser{}), doc.req)
+	assert.Equal(t, reflect.TypeOf(&docUser{}), doc.resp)
+
+	data, err := json.Marshal(NewEngine().OpenAPI())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"openapi":"3.1.0","info":{"title":"API","version":"1.0.0"}}`, string(data))
+}
@@ -0,0 +1,234 @@
+package web
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 参数来源
+const (
+	SourceQuery   = "query"   // 查询参数
+	SourcePath    = "path"    // 路径参数
+	SourceContext = "context" // 通过 Set 设置的值
+	SourceHeader  = "header"  // 请求头
+	SourceForm    = "form"    // 表单
+)
+
+var (
+	ErrParamMissing      = errors.New("param missing")
+	ErrParamTypeMismatch = errors.New("param type mismatch")
+)
+
+// StatusCoder 带有 HTTP 状态码的错误，AbortWithError 会使用该状态码响应
+type StatusCoder interface {
+	StatusCode() int
+}
+
+var _ StatusCoder = &ParamError{}
+
+// ParamError 参数获取或解析失败的错误
+type ParamError struct {
+	Source string // 参数来源
+	Key    string // 参数名
+	Value  string // 原始值
+	Err    error  // 具体原因
+}
+
+func (e *ParamError) Error() string {
+	if errors.Is(e.Err, ErrParamMissing) {
+		return fmt.Sprintf("%s param %q missing", e.Source, e.Key)
+	}
+	return fmt.Sprintf("%s param %q invalid value %q: %v", e.Source, e.Key, e.Value, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode 参数错误属于客户端错误，返回 400
+// 通过 Set 设置的值由服务端自己设置，缺失或类型不对属于服务端错误，返回 500
+func (e *ParamError) StatusCode() int {
+	if e.Source == SourceContext {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// AbortWithError 中止请求处理，并根据错误设置响应
+// 实现了 StatusCoder 的错误使用其状态码和错误信息，其他错误统一返回 500，
+// 5xx 错误不向客户端暴露错误信息
+// 开启 WithProblemDetails 时以 problem+json 响应
+func (c *Context) AbortWithError(err error) {
+	c.Abort()
+	if c.useProblem() {
+		_ = c.Problem(problemFromError(err))
+		return
+	}
+	status := http.StatusInternalServerError
+	msg := http.StatusText(status)
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		status = sc.StatusCode()
+		msg = err.Error()
+		if status >= http.StatusInternalServerError {
+			msg = http.StatusText(status)
+		}
+	}
+	c.StatusCode = status
+	c.RespData = []byte(msg)
+}
+
+// Parsable 可以从字符串解析的参数类型
+type Parsable interface {
+	~string | ~bool |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// GetAs 获取通过 Set 设置的值并断言为 T
+func GetAs[T any](c *Context, key string) (T, error) {
+	var zero T
+	val, ok := c.Get(key)
+	if !ok {
+		return zero, &ParamError{Source: SourceContext, Key: key, Err: ErrParamMissing}
+	}
+	res, ok := val.(T)
+	if !ok {
+		return zero, &ParamError{
+			Source: SourceContext,
+			Key:    key,
+			Value:  fmt.Sprintf("%v", val),
+			Err:    fmt.Errorf("%w: %T is not %T", ErrParamTypeMismatch, val, zero),
+		}
+	}
+	return res, nil
+}
+
+// QueryAs 获取查询参数并解析为 T
+func QueryAs[T Parsable](c *Context, key string) (T, error) {
+	val, ok := c.QueryValue(key)
+	if !ok {
+		var zero T
+		return zero, &ParamError{Source: SourceQuery, Key: key, Err: ErrParamMissing}
+	}
+	return parseParam[T](SourceQuery, key, val)
+}
+
+// PathAs 获取路径参数并解析为 T
+func PathAs[T Parsable](c *Context, key string) (T, error) {
+	val, ok := c.PathValue(key)
+	if !ok {
+		var zero T
+		return zero, &ParamError{Source: SourcePath, Key: key, Err: ErrParamMissing}
+	}
+	return parseParam[T](SourcePath, key, val)
+}
+
+// parseParam 将字符串解析为 T
+func parseParam[T Parsable](source, key, raw string) (T, error) {
+	var res T
+	if err := setValue(reflect.ValueOf(&res).Elem(), raw); err != nil {
+		return res, &ParamError{Source: source, Key: key, Value: raw, Err: err}
+	}
+	return res, nil
+}
+
+// textUnmarshalerType encoding.TextUnmarshaler 的类型
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setValue 将字符串解析后设置到 val，支持 Parsable 类型和实现了 encoding.TextUnmarshaler 的类型
+func setValue(val reflect.Value, raw string) error {
+	if val.CanAddr() && val.Addr().Type().Implements(textUnmarshalerType) {
+		return val.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+	var err error
+	switch val.Kind() {
+	case reflect.String:
+		val.SetString(raw)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(raw); err == nil {
+			val.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(raw, 10, val.Type().Bits()); err == nil {
+			val.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(raw, 10, val.Type().Bits()); err == nil {
+			val.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(raw, val.Type().Bits()); err == nil {
+			val.SetFloat(f)
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", val.Type())
+	}
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+	return err
+}
+
+// QueryInt 获取整数类型的查询参数
+func (c *Context) QueryInt(key string) (int, error) {
+	return QueryAs[int](c, key)
+}
+
+// QueryInt64 获取 int64 类型的查询参数
+func (c *Context) QueryInt64(key string) (int64, error) {
+	return QueryAs[int64](c, key)
+}
+
+// QueryBool 获取布尔类型的查询参数
+func (c *Context) QueryBool(key string) (bool, error) {
+	return QueryAs[bool](c, key)
+}
+
+// QueryDefault 获取查询参数，不存在时返回默认值
+func (c *Context) QueryDefault(key string, defaultValue string) string {
+	val, ok := c.QueryValue(key)
+	if !ok {
+		return defaultValue
+	}
+	return val
+}
+
+// QueryArray 获取同名查询参数的所有值，例如 ?id=1&id=2
+func (c *Context) QueryArray(key string) []string {
+	return c.query()[key]
+}
+
+// QueryMap 获取 map 形式的查询参数，例如 ?filter[name]=tom&filter[age]=18
+func (c *Context) QueryMap(key string) map[string]string {
+	res := make(map[string]string)
+	prefix := key + "["
+	for k, vals := range c.query() {
+		if !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "]") || len(vals) == 0 {
+			continue
+		}
+		res[k[len(prefix):len(k)-1]] = vals[0]
+	}
+	return res
+}
+
+// PathInt 获取整数类型的路径参数
+func (c *Context) PathInt(key string) (int, error) {
+	return PathAs[int](c, key)
+}
+
+// PathInt64 获取 int64 类型的路径参数
+func (c *Context) PathInt64(key string) (int64, error) {
+	return PathAs[int64](c, key)
+}
@@ -0,0 +1,139 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userID uint32
+
+func TestGetAs(t *testing.T) {
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Set("uid", 12)
+
+	uid, err := GetAs[int](ctx, "uid")
+	require.NoError(t, err)
+	assert.Equal(t, 12, uid)
+
+	_, err = GetAs[string](ctx, "uid")
+	assert.ErrorIs(t, err, ErrParamTypeMismatch)
+
+	_, err = GetAs[int](ctx, "missing")
+	assert.ErrorIs(t, err, ErrParamMissing)
+}
+
+func TestGetAs_ServerError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []EngineOption
+		wantBody string
+	}{
+		{name: "text", wantBody: "Internal Server Error"},
+		{name: "problem", opts: []EngineOption{WithProblemDetails()},
+			wantBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/"}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEngine(tc.opts...)
+			e.GET("/", func(ctx *Context) {
+				// 忘记注册设置 uid 的中间件
+				uid, err := GetAs[int](ctx, "uid")
+				if err != nil {
+					ctx.AbortWithError(err)
+					return
+				}
+				_ = ctx.String(http.StatusOK, strconv.Itoa(uid))
+			})
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			if tc.opts == nil {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+				return
+			}
+			assert.JSONEq(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestQueryAs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/?page=2&size=abc&debug=true&ratio=0.5&id=1&id=2&filter[name]=tom&filter[age]=18&uid=7", nil)
+	ctx := newContext(httptest.NewRecorder(), req)
+
+	page, err := ctx.QueryInt("page")
+	require.NoError(t, err)
+	assert.Equal(t, 2, page)
+
+	_, err = ctx.QueryInt("size")
+	var paramErr *ParamError
+	require.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, SourceQuery, paramErr.Source)
+	assert.Equal(t, "abc", paramErr.Value)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+
+	_, err = ctx.QueryInt("missing")
+	assert.ErrorIs(t, err, ErrParamMissing)
+
+	debug, err := ctx.QueryBool("debug")
+	require.NoError(t, err)
+	assert.True(t, debug)
+
+	ratio, err := QueryAs[float64](ctx, "ratio")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, ratio)
+
+	uid, err := QueryAs[userID](ctx, "uid")
+	require.NoError(t, err)
+	assert.Equal(t, userID(7), uid)
+
+	_, err = QueryAs[int8](ctx, "size")
+	assert.Error(t, err)
+
+	assert.Equal(t, "10", ctx.QueryDefault("limit", "10"))
+	assert.Equal(t, "2", ctx.QueryDefault("page", "10"))
+	assert.Equal(t, []string{"1", "2"}, ctx.QueryArray("id"))
+	assert.Equal(t, map[string]string{"name": "tom", "age": "18"}, ctx.QueryMap("filter"))
+}
+
+func TestPathAs(t *testing.T) {
+	e := NewEngine()
+	e.GET("/user/:id", func(ctx *Context) {
+		id, err := ctx.PathInt64("id")
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		_ = ctx.String(http.StatusOK, strconv.FormatInt(id, 10))
+	})
+	e.GET("/fail", func(ctx *Context) {
+		ctx.AbortWithError(errors.New("db down"))
+	})
+
+	testCases := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "ok", path: "/user/12", wantCode: http.StatusOK, wantBody: "12"},
+		{name: "bad param", path: "/user/abc", wantCode: http.StatusBadRequest,
+			wantBody: `path param "id" invalid value "abc": invalid syntax`},
+		{name: "internal error", path: "/fail", wantCode: http.StatusInternalServerError,
+			wantBody: "Internal Server Error"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ProblemContentType RFC 9457 问题详情的内容类型
+const ProblemContentType = "application/problem+json"
+
+var _ StatusCoder = &Problem{}
+
+// Problem RFC 9457 问题详情
+type Problem struct {
+	Type       string         `json:"type,omitempty"`     // 问题类型的 URI，默认为 about:blank
+	Title      string         `json:"title,omitempty"`    // 问题类型的简短描述
+	Status     int            `json:"status,omitempty"`   // HTTP 状态码
+	Detail     string         `json:"detail,omitempty"`   // 本次问题的具体描述
+	Instance   string         `json:"instance,omitempty"` // 本次问题的 URI，默认为请求路径
+	Extensions map[string]any `json:"-"`                  // 扩展字段，与标准字段平铺在同一层
+}
+
+// NewProblem 创建问题详情，title 默认为状态码对应的描述
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// With 添加扩展字段
+func (p *Problem) With(key string, val any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[key] = val
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Title + ": " + p.Detail
+	}
+	return p.Title
+}
+
+// StatusCode 返回问题详情的状态码
+func (p *Problem) StatusCode() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+// MarshalJSON 将扩展字段与标准字段平铺输出，扩展字段不能覆盖标准字段
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	res := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		res[k] = v
+	}
+	type problem Problem
+	data, err := json.Marshal((*problem)(p))
+	if err != nil {
+		return nil, err
+	}
+	var std map[string]any
+	if err = json.Unmarshal(data, &std); err != nil {
+		return nil, err
+	}
+	for k, v := range std {
+		res[k] = v
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON 解析问题详情，非标准字段放入扩展字段
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	type problem Problem
+	if err := json.Unmarshal(data, (*problem)(p)); err != nil {
+		return err
+	}
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, k := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(all, k)
+	}
+	if len(all) > 0 {
+		p.Extensions = all
+	}
+	return nil
+}
+
+// WithProblemDetails 使内置的 404、405、panic 恢复、参数及请求体解析错误都以 problem+json 响应
+func WithProblemDetails() EngineOption {
+	return func(e *Engine) {
+		e.problemDetails = true
+	}
+}
+
+// Problem 发送 application/problem+json 响应，Instance 默认为请求路径
+// p 通常是共享的错误变量，不会被修改
+func (c *Context) Problem(p *Problem) error {
+	cp := *p
+	if cp.Instance == "" && c.Req != nil {
+		cp.Instance = c.Req.URL.Path
+	}
+	data, err := json.Marshal(&cp)
+	if err != nil {
+		return err
+	}
+	c.Resp.Header().Set("Content-Type", ProblemContentType)
+	c.StatusCode = cp.StatusCode()
+	c.RespData = data
+	return nil
+}
+
+// useProblem 判断是否以 problem+json 响应内置错误
+func (c *Context) useProblem() bool {
+	return c.engine != nil && c.engine.problemDetails
+}
+
+// problemFromError 将错误转换为问题详情
+func problemFromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		cp := *p
+		return &cp
+	}
+	var sc StatusCoder
+	if !errors.As(err, &sc) {
+		// 服务端错误不向客户端暴露细节
+		return NewProblem(http.StatusInternalServerError, "")
+	}
+	if sc.StatusCode() >= http.StatusInternalServerError {
+		return NewProblem(sc.StatusCode(), "")
+	}
+	res := NewProblem(sc.StatusCode(), err.Error())
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		res.With("in", paramErr.Source).With("param", paramErr.Key)
+	}
+	return res
+}
+
+// allowedMethods 返回能够匹配 path 的请求方法
+func (r *router) allowedMethods(path string) []string {
+	var res []string
+	for method := range r.trees {
+		if info, ok := r.findRoute(method, path); ok && info.node.handlers != nil {
+			res = append(res, method)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// WithMethodNotAllowed 开启 405 响应：路径存在但请求方法不匹配时调用 405 处理函数，而不是 404
+func WithMethodNotAllowed() EngineOption {
+	return func(e *Engine) {
+		e.handleMethodNotAllowed = true
+	}
+}
+
+// WithMethodNotAllowedHandler 设置 405 处理函数，同时开启 405 响应
+func WithMethodNotAllowedHandler(h HandleFunc) EngineOption {
+	return func(e *Engine) {
+		e.handleMethodNotAllowed = true
+		e.MethodNotAllowedHandler = h
+	}
+}
+
+// DefaultMethodNotAllowedHandler 默认的405处理函数，Allow 响应头由引擎设置
+var DefaultMethodNotAllowedHandler = func(ctx *Context) {
+	if ctx.useProblem() {
+		_ = ctx.Problem(NewProblem(http.StatusMethodNotAllowed,
+			"allowed methods: "+strings.Join(ctx.Resp.Header().Values("Allow"), ", ")))
+		return
+	}
+	ctx.StatusCode = http.StatusMethodNotAllowed
+	ctx.RespData = []byte("405 method not allowed")
+}
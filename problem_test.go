@@ -0,0 +1,132 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	p := NewProblem(http.StatusForbidden, "not enough credit").
+		With("balance", 30).
+		With("status", "overridden")
+	p.Type = "https://example.com/probs/out-of-credit"
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "https://example.com/probs/out-of-credit",
+		"title": "Forbidden",
+		"status": 403,
+		"detail": "not enough credit",
+		"balance": 30
+	}`, string(data))
+
+	var got Problem
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, 403, got.Status)
+	assert.Equal(t, map[string]any{"balance": float64(30)}, got.Extensions)
+}
+
+func TestEngine_ProblemDetails(t *testing.T) {
+	e := NewEngine(WithProblemDetails(), WithMethodNotAllowed())
+	e.Use(RecoverBuilder{LogFunc: func(string) {}}.Build())
+	e.GET("/user/:id", func(ctx *Context) {
+		if _, err := ctx.PathInt("id"); err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		_ = ctx.Problem(NewProblem(http.StatusConflict, "user locked").With("retry", true))
+	})
+	e.POST("/user", func(ctx *Context) {
+		var val map[string]any
+		if err := ctx.BindJSON(&val); err != nil {
+			ctx.AbortWithError(err)
+		}
+	})
+	e.GET("/panic", func(ctx *Context) {
+		panic("boom")
+	})
+	e.GET("/internal", func(ctx *Context) {
+		ctx.AbortWithError(errors.New("password=secret"))
+	})
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantJSON   string
+		wantAllow  string
+	}{
+		{name: "custom", method: http.MethodGet, path: "/user/1", wantStatus: http.StatusConflict,
+			wantJSON: `{"type":"about:blank","title":"Conflict","status":409,"detail":"user locked","instance":"/user/1","retry":true}`},
+		{name: "param", method: http.MethodGet, path: "/user/abc", wantStatus: http.StatusBadRequest,
+			wantJSON: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"path param \"id\" invalid value \"abc\": invalid syntax","instance":"/user/abc","in":"path","param":"id"}`},
+		{name: "bind", method: http.MethodPost, path: "/user", body: "{", wantStatus: http.StatusBadRequest,
+			wantJSON: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bind: unexpected EOF","instance":"/user"}`},
+		{name: "not found", method: http.MethodGet, path: "/missing", wantStatus: http.StatusNotFound,
+			wantJSON: `{"type":"about:blank","title":"Not Found","status":404,"detail":"no route matches /missing","instance":"/missing"}`},
+		{name: "method not allowed", method: http.MethodDelete, path: "/user", wantStatus: http.StatusMethodNotAllowed,
+			wantAllow: "POST",
+			wantJSON:  `{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"allowed methods: POST","instance":"/user"}`},
+		{name: "recover", method: http.MethodGet, path: "/panic", wantStatus: http.StatusInternalServerError,
+			wantJSON: `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/panic"}`},
+		{name: "internal error hides detail", method: http.MethodGet, path: "/internal", wantStatus: http.StatusInternalServerError,
+			wantJSON: `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/internal"}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.wantStatus, recorder.Code)
+			assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.wantJSON, recorder.Body.String())
+			assert.Equal(t, tc.wantAllow, recorder.Header().Get("Allow"))
+		})
+	}
+}
+
+func TestEngine_MethodNotAllowed(t *testing.T) {
+	e := NewEngine()
+	e.GET("/user", mockHandler)
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/user", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	e = NewEngine(WithMethodNotAllowed())
+	e.GET("/user", mockHandler)
+	e.PUT("/user", mockHandler)
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/user", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, "GET, PUT", recorder.Header().Get("Allow"))
+	assert.Equal(t, "405 method not allowed", recorder.Body.String())
+}
+
+func TestContext_ProblemShared(t *testing.T) {
+	errLocked := NewProblem(http.StatusConflict, "user locked")
+	e := NewEngine(WithProblemDetails())
+	e.GET("/send/:name", func(ctx *Context) {
+		_ = ctx.Problem(errLocked)
+	})
+	e.GET("/abort/:name", func(ctx *Context) {
+		ctx.AbortWithError(fmt.Errorf("lock user: %w", errLocked))
+	})
+
+	for _, path := range []string{"/send/first", "/send/second", "/abort/first", "/abort/second"} {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.JSONEq(t, `{"type":"about:blank","title":"Conflict","status":409,"detail":"user locked","instance":"`+path+`"}`,
+			recorder.Body.String())
+	}
+	assert.Empty(t, errLocked.Instance)
+}
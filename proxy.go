@@ -0,0 +1,216 @@
+package web
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// WithTrustedProxies 设置受信任的代理，支持 CIDR 和单个 IP
+// 只有请求直接来自受信任的代理时，才会使用 Forwarded、X-Forwarded-For、X-Real-IP 等请求头
+func WithTrustedProxies(proxies ...string) EngineOption {
+	return func(e *Engine) {
+		prefixes := make([]netip.Prefix, 0, len(proxies))
+		for _, p := range proxies {
+			prefix, err := parseTrustedProxy(p)
+			if err != nil {
+				panic("invalid trusted proxy " + p + ": " + err.Error())
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		e.trustedProxies = prefixes
+	}
+}
+
+// parseTrustedProxy 解析 CIDR 或单个 IP
+func parseTrustedProxy(p string) (netip.Prefix, error) {
+	if strings.Contains(p, "/") {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(p)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// isTrustedProxy 判断地址是否属于受信任的代理
+func (e *Engine) isTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range e.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddr 解析直接连接的对端地址
+func (c *Context) remoteAddr() (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(c.Req.RemoteAddr)
+	if err != nil {
+		host = c.Req.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// fromTrustedProxy 判断请求是否直接来自受信任的代理
+func (c *Context) fromTrustedProxy() bool {
+	if c.engine == nil || len(c.engine.trustedProxies) == 0 {
+		return false
+	}
+	addr, ok := c.remoteAddr()
+	return ok && c.engine.isTrustedProxy(addr)
+}
+
+// ClientIP 返回客户端的真实 IP
+// 请求来自受信任的代理时，依次使用 Forwarded、X-Forwarded-For、X-Real-IP，
+// 代理链从右向左解析，跳过受信任的代理，第一个不受信任的地址即为客户端地址，以防止伪造
+func (c *Context) ClientIP() string {
+	remote, ok := c.remoteAddr()
+	if !ok {
+		return c.Req.RemoteAddr
+	}
+	if !c.fromTrustedProxy() {
+		return remote.String()
+	}
+
+	if chain := forwardedValues(c.Req.Header.Values("Forwarded"), "for"); len(chain) > 0 {
+		return c.resolveChain(chain, remote)
+	}
+	if chain := splitHeaderList(c.Req.Header.Values("X-Forwarded-For")); len(chain) > 0 {
+		return c.resolveChain(chain, remote)
+	}
+	if realIP := strings.TrimSpace(c.Req.Header.Get("X-Real-IP")); realIP != "" {
+		if addr, err := netip.ParseAddr(realIP); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	return remote.String()
+}
+
+// resolveChain 从右向左解析代理链
+func (c *Context) resolveChain(chain []string, remote netip.Addr) string {
+	last := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseNode(chain[i])
+		if !ok {
+			// 无法解析的地址不可信，返回把它转发过来的代理
+			return last.String()
+		}
+		if !c.engine.isTrustedProxy(addr) {
+			return addr.String()
+		}
+		last = addr
+	}
+	// 整条链都是受信任的代理，最左侧的地址即为客户端
+	return last.String()
+}
+
+// Scheme 返回客户端请求使用的协议(http 或 https)
+// 请求来自受信任的代理时使用 Forwarded 的 proto 或 X-Forwarded-Proto
+func (c *Context) Scheme() string {
+	if c.fromTrustedProxy() {
+		if protos := forwardedValues(c.Req.Header.Values("Forwarded"), "proto"); len(protos) > 0 {
+			return strings.ToLower(protos[len(protos)-1])
+		}
+		if protos := splitHeaderList(c.Req.Header.Values("X-Forwarded-Proto")); len(protos) > 0 {
+			return strings.ToLower(protos[len(protos)-1])
+		}
+	}
+	if c.Req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host 返回客户端请求的主机名
+// 请求来自受信任的代理时使用 Forwarded 的 host 或 X-Forwarded-Host
+func (c *Context) Host() string {
+	if c.fromTrustedProxy() {
+		if hosts := forwardedValues(c.Req.Header.Values("Forwarded"), "host"); len(hosts) > 0 {
+			return hosts[len(hosts)-1]
+		}
+		if hosts := splitHeaderList(c.Req.Header.Values("X-Forwarded-Host")); len(hosts) > 0 {
+			return hosts[len(hosts)-1]
+		}
+	}
+	return c.Req.Host
+}
+
+// splitHeaderList 拆分以逗号分隔的多个请求头
+func splitHeaderList(values []string) []string {
+	var res []string
+	for _, val := range values {
+		for _, item := range strings.Split(val, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				res = append(res, item)
+			}
+		}
+	}
+	return res
+}
+
+// forwardedValues 按顺序返回 RFC 7239 Forwarded 头中每个元素指定参数的值
+func forwardedValues(values []string, key string) []string {
+	var res []string
+	for _, val := range values {
+		for _, element := range splitQuoted(val, ',') {
+			for _, pair := range splitQuoted(element, ';') {
+				k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(k), key) {
+					continue
+				}
+				v = strings.TrimSpace(v)
+				if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+					v = strings.ReplaceAll(v[1:len(v)-1], `\"`, `"`)
+				}
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// splitQuoted 按分隔符拆分字符串，忽略引号内的分隔符
+func splitQuoted(s string, sep byte) []string {
+	var res []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if quoted {
+				i++
+			}
+		case sep:
+			if !quoted {
+				res = append(res, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(res, s[start:])
+}
+
+// parseNode 解析代理链中的节点，支持 IPv4、IPv4:port、[IPv6]:port 和 IPv6
+func parseNode(node string) (netip.Addr, bool) {
+	node = strings.TrimSpace(node)
+	if addr, err := netip.ParseAddr(strings.Trim(node, "[]")); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(node); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_ClientIP(t *testing.T) {
+	e := NewEngine(WithTrustedProxies("10.0.0.0/8", "192.168.1.1", "2001:db8::/32"))
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "no proxy",
+			remoteAddr: "1.2.3.4:1234",
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "untrusted remote ignores headers",
+			remoteAddr: "1.2.3.4:1234",
+			header:     http.Header{"X-Forwarded-For": []string{"8.8.8.8"}},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "x-forwarded-for",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": []string{"8.8.8.8, 10.0.0.2"}},
+			want:       "8.8.8.8",
+		},
+		{
+			name:       "x-forwarded-for spoofed",
+			remoteAddr: "10.0.0.1:1234",
+			// 客户端伪造了 1.1.1.1，真实地址由受信任代理追加在右侧
+			header: http.Header{"X-Forwarded-For": []string{"1.1.1.1, 8.8.8.8", "10.0.0.2"}},
+			want:   "8.8.8.8",
+		},
+		{
+			name:       "all trusted",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": []string{"192.168.1.1, 10.0.0.2"}},
+			want:       "192.168.1.1",
+		},
+		{
+			name:       "invalid entry",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": []string{"garbage, 10.0.0.2"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "forwarded",
+			remoteAddr: "[2001:db8::1]:443",
+			header: http.Header{
+				"Forwarded":       []string{`for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.3`},
+				"X-Forwarded-For": []string{"9.9.9.9"},
+			},
+			want: "2001:db8:cafe::17",
+		},
+		{
+			name:       "forwarded untrusted ipv6",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"Forwarded": []string{`for=192.0.2.60;proto=http;by=203.0.113.43`}},
+			want:       "192.0.2.60",
+		},
+		{
+			name:       "x-real-ip",
+			remoteAddr: "192.168.1.1:80",
+			header:     http.Header{"X-Real-Ip": []string{"7.7.7.7"}},
+			want:       "7.7.7.7",
+		},
+		{
+			name:       "ipv4 mapped remote",
+			remoteAddr: "[::ffff:10.0.0.1]:80",
+			header:     http.Header{"X-Forwarded-For": []string{"8.8.4.4"}},
+			want:       "8.8.4.4",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+			ctx := newContext(httptest.NewRecorder(), req)
+			ctx.engine = e
+			assert.Equal(t, tc.want, ctx.ClientIP())
+		})
+	}
+}
+
+func TestContext_SchemeAndHost(t *testing.T) {
+	e := NewEngine(WithTrustedProxies("10.0.0.0/8"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://internal.local/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("Forwarded", `for=1.2.3.4;proto=https;host="example.com"`)
+	ctx := newContext(httptest.NewRecorder(), req)
+	ctx.engine = e
+	assert.Equal(t, "https", ctx.Scheme())
+	assert.Equal(t, "example.com", ctx.Host())
+
+	req = httptest.NewRequest(http.MethodGet, "http://internal.local/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-Proto", "HTTPS")
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+	ctx = newContext(httptest.NewRecorder(), req)
+	ctx.engine = e
+	assert.Equal(t, "https", ctx.Scheme())
+	assert.Equal(t, "api.example.com", ctx.Host())
+
+	// 不受信任的来源
+	req = httptest.NewRequest(http.MethodGet, "http://internal.local/", nil)
+	req.RemoteAddr = "1.2.3.4:1234"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "evil.com")
+	ctx = newContext(httptest.NewRecorder(), req)
+	ctx.engine = e
+	assert.Equal(t, "http", ctx.Scheme())
+	assert.Equal(t, "internal.local", ctx.Host())
+
+	req.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "https", ctx.Scheme())
+}
+
+func TestWithTrustedProxies(t *testing.T) {
+	assert.Panics(t, func() {
+		NewEngine(WithTrustedProxies("not an ip"))
+	})
+}
@@ -0,0 +1,174 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var ErrRedirectNotAllowed = errors.New("redirect target not allowed")
+
+// jsonpCallbackPattern 合法的 JSONP 回调函数名
+var jsonpCallbackPattern = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// WithRedirectAllowList 设置允许重定向到的外部主机，支持 *.example.com 形式
+// 未在列表中的外部主机会被 Redirect 拒绝，以防止开放重定向
+func WithRedirectAllowList(hosts ...string) EngineOption {
+	return func(e *Engine) {
+		e.redirectAllowList = hosts
+	}
+}
+
+// Redirect 重定向到 location
+// 相对地址基于当前请求地址解析；重定向到外部主机时，主机必须在 Engine 的允许列表中
+func (c *Context) Redirect(code int, location string) error {
+	if (code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect) && code != http.StatusCreated {
+		return fmt.Errorf("invalid redirect status code %d", code)
+	}
+	// 浏览器会把反斜杠当作斜杠处理，/\evil.com 会被当作 //evil.com
+	if strings.Contains(location, `\`) {
+		return fmt.Errorf("%w: %s", ErrRedirectNotAllowed, location)
+	}
+	target, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	if target.Scheme != "" {
+		// 浏览器会把 https:evil.com 和 https:/evil.com 当作 https://evil.com，
+		// 带协议的地址必须是 scheme://host 的完整形式
+		if target.Scheme != "http" && target.Scheme != "https" || target.Opaque != "" || target.Host == "" ||
+			!strings.HasPrefix(location[len(target.Scheme)+1:], "//") {
+			return fmt.Errorf("%w: %s", ErrRedirectNotAllowed, location)
+		}
+	}
+	if target.Host != "" && !strings.EqualFold(target.Host, c.Host()) && !c.redirectAllowed(target.Hostname()) {
+		return fmt.Errorf("%w: %s", ErrRedirectNotAllowed, location)
+	}
+	if target.Scheme == "" && target.Host == "" {
+		target = c.Req.URL.ResolveReference(target)
+		// 请求地址通常只有路径，保持相对地址的形式
+		target.Scheme = ""
+		target.Host = ""
+		// 没有主机的 ///evil.com 会原样输出，浏览器同样会把它当作 //evil.com
+		if strings.HasPrefix(target.Path, "//") {
+			return fmt.Errorf("%w: %s", ErrRedirectNotAllowed, location)
+		}
+	}
+
+	c.Resp.Header().Set("Location", target.String())
+	c.StatusCode = code
+	c.RespData = nil
+	return nil
+}
+
+// redirectAllowed 判断主机是否在重定向允许列表中
+func (c *Context) redirectAllowed(host string) bool {
+	if c.engine == nil {
+		return false
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range c.engine.redirectAllowList {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Header 设置响应头
+func (c *Context) Header(key string, val string) {
+	c.Resp.Header().Set(key, val)
+}
+
+// AddHeader 追加响应头
+func (c *Context) AddHeader(key string, val string) {
+	c.Resp.Header().Add(key, val)
+}
+
+// GetHeader 获取请求头
+func (c *Context) GetHeader(key string) string {
+	return c.Req.Header.Get(key)
+}
+
+// NoContent 发送没有响应体的响应，通常为 204
+func (c *Context) NoContent(status int) {
+	c.StatusCode = status
+	c.RespData = nil
+}
+
+// Data 发送指定内容类型的响应
+func (c *Context) Data(status int, contentType string, data []byte) error {
+	c.Resp.Header().Set("Content-Type", contentType)
+	c.StatusCode = status
+	c.RespData = data
+	return nil
+}
+
+// PureJSON 发送JSON响应，不对 <、>、& 等 HTML 字符进行转义
+func (c *Context) PureJSON(status int, val any) error {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(val); err != nil {
+		return err
+	}
+	return c.Data(status, "application/json", bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// IndentedJSON 发送带缩进的JSON响应
+func (c *Context) IndentedJSON(status int, val any) error {
+	data, err := json.MarshalIndent(val, "", "    ")
+	if err != nil {
+		return err
+	}
+	return c.Data(status, "application/json", data)
+}
+
+// JSONP 发送JSONP响应，回调函数名取自查询参数 callback
+// 没有回调函数时发送普通JSON响应，回调函数名不合法时返回错误
+func (c *Context) JSONP(status int, val any) error {
+	callback, ok := c.QueryValue("callback")
+	if !ok || callback == "" {
+		return c.JSON(status, val)
+	}
+	if !jsonpCallbackPattern.MatchString(callback) {
+		return &ParamError{Source: SourceQuery, Key: "callback", Value: callback, Err: errors.New("invalid callback name")}
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	// 前置注释用于防御 Rosetta Flash 等内容嗅探攻击
+	body := make([]byte, 0, len(callback)+len(data)+8)
+	body = append(body, "/**/"...)
+	body = append(body, callback...)
+	body = append(body, '(')
+	body = append(body, data...)
+	body = append(body, ");"...)
+	c.Resp.Header().Set("X-Content-Type-Options", "nosniff")
+	return c.Data(status, "application/javascript", body)
+}
+
+// AbortWithStatus 中止请求处理并设置响应状态码
+func (c *Context) AbortWithStatus(status int) {
+	c.StatusCode = status
+	c.Abort()
+}
+
+// AbortWithJSON 中止请求处理并发送JSON响应
+func (c *Context) AbortWithJSON(status int, val any) error {
+	c.Abort()
+	return c.JSON(status, val)
+}
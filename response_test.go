@@ -0,0 +1,143 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_Redirect(t *testing.T) {
+	e := NewEngine(WithRedirectAllowList("sso.example.com", "*.trusted.com"))
+
+	testCases := []struct {
+		name         string
+		url          string
+		code         int
+		location     string
+		wantErr      bool
+		wantLocation string
+	}{
+		{name: "absolute path", url: "/a/b", code: http.StatusFound, location: "/login?next=%2Fa", wantLocation: "/login?next=%2Fa"},
+		{name: "relative path", url: "/user/12/edit", code: http.StatusSeeOther, location: "../13", wantLocation: "/user/13"},
+		{name: "same host", url: "http://example.com/a", code: http.StatusFound, location: "http://example.com/b", wantLocation: "http://example.com/b"},
+		{name: "allowed host", url: "/a", code: http.StatusFound, location: "https://sso.example.com/login", wantLocation: "https://sso.example.com/login"},
+		{name: "allowed wildcard", url: "/a", code: http.StatusFound, location: "https://api.trusted.com/x", wantLocation: "https://api.trusted.com/x"},
+		{name: "wildcard not apex", url: "/a", code: http.StatusFound, location: "https://trusted.com/x", wantErr: true},
+		{name: "open redirect", url: "/a", code: http.StatusFound, location: "https://evil.com", wantErr: true},
+		{name: "scheme relative", url: "/a", code: http.StatusFound, location: "//evil.com/x", wantErr: true},
+		{name: "triple slash", url: "/a", code: http.StatusFound, location: "///evil.com", wantErr: true},
+		{name: "quadruple slash", url: "/a", code: http.StatusFound, location: "////evil.com", wantErr: true},
+		{name: "backslash", url: "/a", code: http.StatusFound, location: `/\evil.com`, wantErr: true},
+		{name: "scheme without slashes", url: "/a", code: http.StatusFound, location: "https:evil.com", wantErr: true},
+		{name: "scheme with one slash", url: "/a", code: http.StatusFound, location: "https:/evil.com", wantErr: true},
+		{name: "scheme with backslashes", url: "/a", code: http.StatusFound, location: `http:\\evil.com`, wantErr: true},
+		{name: "uppercase scheme", url: "/a", code: http.StatusFound, location: "HTTPS://evil.com", wantErr: true},
+		{name: "allowed host uppercase scheme", url: "/a", code: http.StatusFound, location: "HTTPS://sso.example.com/login", wantLocation: "https://sso.example.com/login"},
+		{name: "javascript", url: "/a", code: http.StatusFound, location: "javascript:alert(1)", wantErr: true},
+		{name: "invalid code", url: "/a", code: http.StatusOK, location: "/b", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx := newContext(recorder, httptest.NewRequest(http.MethodGet, tc.url, nil))
+			ctx.engine = e
+			err := ctx.Redirect(tc.code, tc.location)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, recorder.Header().Get("Location"))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.code, ctx.StatusCode)
+			assert.Equal(t, tc.wantLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestContext_ResponseHelpers(t *testing.T) {
+	e := NewEngine()
+	e.GET("/header", func(ctx *Context) {
+		ctx.Header("X-Request-Id", ctx.GetHeader("X-Request-Id"))
+		ctx.AddHeader("Vary", "Origin")
+		ctx.AddHeader("Vary", "Accept")
+		ctx.NoContent(http.StatusNoContent)
+	})
+	e.GET("/data", func(ctx *Context) {
+		_ = ctx.Data(http.StatusOK, "image/png", []byte{0x89, 'P', 'N', 'G'})
+	})
+	e.GET("/pure", func(ctx *Context) {
+		_ = ctx.PureJSON(http.StatusOK, map[string]string{"html": "<b>&</b>"})
+	})
+	e.GET("/json", func(ctx *Context) {
+		_ = ctx.JSON(http.StatusOK, map[string]string{"html": "<b>&</b>"})
+	})
+	e.GET("/indented", func(ctx *Context) {
+		_ = ctx.IndentedJSON(http.StatusOK, map[string]int{"a": 1})
+	})
+	e.GET("/jsonp", func(ctx *Context) {
+		if err := ctx.JSONP(http.StatusOK, map[string]int{"a": 1}); err != nil {
+			ctx.AbortWithError(err)
+		}
+	})
+	e.GET("/abort", func(ctx *Context) {
+		ctx.AbortWithStatus(http.StatusForbidden)
+	}, func(ctx *Context) {
+		t.Fatal("handler after abort must not run")
+	})
+	e.GET("/abort-json", func(ctx *Context) {
+		_ = ctx.AbortWithJSON(http.StatusTooManyRequests, map[string]string{"error": "slow down"})
+	}, func(ctx *Context) {
+		t.Fatal("handler after abort must not run")
+	})
+
+	testCases := []struct {
+		name       string
+		path       string
+		header     http.Header
+		wantCode   int
+		wantBody   string
+		wantHeader http.Header
+	}{
+		{
+			name:     "header",
+			path:     "/header",
+			header:   http.Header{"X-Request-Id": []string{"abc"}},
+			wantCode: http.StatusNoContent,
+			wantHeader: http.Header{
+				"X-Request-Id": []string{"abc"},
+				"Vary":         []string{"Origin", "Accept"},
+			},
+		},
+		{name: "data", path: "/data", wantCode: http.StatusOK, wantBody: "\x89PNG",
+			wantHeader: http.Header{"Content-Type": []string{"image/png"}}},
+		{name: "pure json", path: "/pure", wantCode: http.StatusOK, wantBody: `{"html":"<b>&</b>"}`},
+		{name: "json", path: "/json", wantCode: http.StatusOK, wantBody: `{"html":"\u003cb\u003e\u0026\u003c/b\u003e"}`},
+		{name: "indented json", path: "/indented", wantCode: http.StatusOK, wantBody: "{\n    \"a\": 1\n}"},
+		{name: "jsonp", path: "/jsonp?callback=app.handle", wantCode: http.StatusOK, wantBody: `/**/app.handle({"a":1});`,
+			wantHeader: http.Header{"Content-Type": []string{"application/javascript"}}},
+		{name: "jsonp without callback", path: "/jsonp", wantCode: http.StatusOK, wantBody: `{"a":1}`},
+		{name: "jsonp invalid callback", path: "/jsonp?callback=alert(1)", wantCode: http.StatusBadRequest},
+		{name: "abort", path: "/abort", wantCode: http.StatusForbidden},
+		{name: "abort json", path: "/abort-json", wantCode: http.StatusTooManyRequests, wantBody: `{"error":"slow down"}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+			}
+			for k, v := range tc.wantHeader {
+				assert.Equal(t, v, recorder.Header()[k])
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 平滑重启时传递给新进程的环境变量
+const (
+	envListeners = "GOWEB_LISTENERS" // 继承的监听地址列表(JSON)，文件描述符从 3 开始依次对应
+	envReadyFD   = "GOWEB_READY_FD"  // 新进程就绪后写入的管道文件描述符
+)
+
+var ErrRestartNotReady = errors.New("web: restarted process exited before ready")
+
+// inheritedAddr 继承的监听地址
+type inheritedAddr struct {
+	Network string `json:"network"`
+	Addr    string `json:"addr"`
+}
+
+// inherited 从父进程继承的监听
+var inherited struct {
+	once      sync.Once
+	mu        sync.Mutex
+	listeners []net.Listener
+}
+
+// WithRestartSignals 设置触发平滑重启的信号，例如 syscall.SIGHUP，只对 Run 和 RunListeners 生效
+// 收到信号后调用 Restart，重启失败时记录日志并继续提供服务
+func WithRestartSignals(signals ...os.Signal) EngineOption {
+	return func(e *Engine) {
+		e.lifecycle.restartSignals = signals
+	}
+}
+
+// WithRestartCommand 设置平滑重启时执行的命令，默认为当前程序及其参数，升级时通常无需设置
+func WithRestartCommand(path string, args ...string) EngineOption {
+	return func(e *Engine) {
+		e.lifecycle.restartCmd = append([]string{path}, args...)
+	}
+}
+
+// Restart 平滑重启：启动新进程并将正在使用的监听传递给它，
+// 等待新进程开始提供服务后优雅关闭当前引擎，期间监听不会关闭，不会丢失连接
+// ctx 控制等待新进程就绪的时间，超时后结束新进程，当前引擎继续提供服务
+// 新进程使用 Run、Start、StartTLS 或 StartUnix 监听相同的地址时会直接使用继承的监听
+func (e *Engine) Restart(ctx context.Context) error {
+	lc := e.lifecycle
+	lc.mu.Lock()
+	listeners := append([]net.Listener(nil), lc.listeners...)
+	lc.mu.Unlock()
+	if len(listeners) == 0 {
+		return errors.New("web: no listener to pass on")
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	addrs := make([]inheritedAddr, 0, len(listeners))
+	for _, l := range listeners {
+		fl, ok := l.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("web: listener %s does not support passing on", l.Addr())
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		addrs = append(addrs, inheritedAddr{Network: l.Addr().Network(), Addr: l.Addr().String()})
+	}
+	addrData, err := json.Marshal(addrs)
+	if err != nil {
+		return err
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+	files = append(files, readyW)
+
+	cmdArgs := lc.restartCmd
+	if len(cmdArgs) == 0 {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		cmdArgs = append([]string{exe}, os.Args[1:]...)
+	}
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(restartEnv(),
+		envListeners+"="+string(addrData),
+		// ExtraFiles 中的第 i 个文件在新进程中为 3+i
+		envReadyFD+"="+strconv.Itoa(listenFDsStart+len(files)-1))
+	err = cmd.Start()
+	for _, l := range listeners {
+		setNonblock(l)
+	}
+	if err != nil {
+		return err
+	}
+	// 关闭写端，新进程退出时读端才能读到 EOF
+	_ = readyW.Close()
+	files = files[:len(files)-1]
+
+	readyCh := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(readyR, make([]byte, 1))
+		readyCh <- err
+	}()
+	select {
+	case err = <-readyCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			err = ErrRestartNotReady
+		}
+		return err
+	}
+	// 新进程会在当前进程退出后由 init 回收，这里等待是为了避免当前进程存活期间成为僵尸进程
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	for _, l := range listeners {
+		// 关闭监听时不删除 socket 文件，新进程仍在使用
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	return e.shutdownWithTimeout()
+}
+
+// restartWithTimeout 使用 WithShutdownTimeout 设置的超时时间等待新进程就绪
+func (e *Engine) restartWithTimeout(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, e.lifecycle.shutdownTimeout)
+	defer cancel()
+	return e.Restart(ctx)
+}
+
+// restartEnv 返回去掉了继承相关变量的当前环境变量
+func restartEnv() []string {
+	env := os.Environ()
+	res := make([]string, 0, len(env))
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envListeners+"=") || strings.HasPrefix(kv, envReadyFD+"=") ||
+			strings.HasPrefix(kv, "LISTEN_") {
+			continue
+		}
+		res = append(res, kv)
+	}
+	return res
+}
+
+// loadInherited 加载从父进程继承的监听
+func loadInherited() {
+	data := os.Getenv(envListeners)
+	if data == "" {
+		return
+	}
+	_ = os.Unsetenv(envListeners)
+	var addrs []inheritedAddr
+	if err := json.Unmarshal([]byte(data), &addrs); err != nil {
+		return
+	}
+	listeners, err := listenersFromFDs(listenFDsStart, len(addrs), "")
+	if err != nil {
+		return
+	}
+	for _, l := range listeners {
+		inherited.listeners = append(inherited.listeners, l.Listener)
+	}
+}
+
+// takeInherited 取出与 network 和 addr 匹配的继承监听，没有时返回 nil
+func takeInherited(network string, addr string) net.Listener {
+	inherited.once.Do(loadInherited)
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+	for i, l := range inherited.listeners {
+		if addrMatches(network, addr, l.Addr()) {
+			inherited.listeners = append(inherited.listeners[:i], inherited.listeners[i+1:]...)
+			return l
+		}
+	}
+	return nil
+}
+
+// addrMatches 判断监听地址 la 是否满足 network 和 addr 的监听请求
+func addrMatches(network string, addr string, la net.Addr) bool {
+	switch network {
+	case "unix":
+		return la.Network() == "unix" && la.String() == addr
+	case "tcp", "tcp4", "tcp6":
+		tcpAddr, ok := la.(*net.TCPAddr)
+		if !ok {
+			return false
+		}
+		want, err := net.ResolveTCPAddr(network, addr)
+		if err != nil || want.Port == 0 || want.Port != tcpAddr.Port {
+			return false
+		}
+		if want.IP == nil || want.IP.IsUnspecified() {
+			return tcpAddr.IP.IsUnspecified()
+		}
+		return want.IP.Equal(tcpAddr.IP)
+	}
+	return false
+}
+
+// listen 优先使用从父进程继承的监听
+func listen(network string, addr string) (net.Listener, error) {
+	if l := takeInherited(network, addr); l != nil {
+		return l, nil
+	}
+	return net.Listen(network, addr)
+}
+
+// notifyParentReady 通知父进程当前进程已经开始提供服务，只会通知一次
+var notifyParentReady = sync.OnceFunc(func() {
+	fd, err := strconv.Atoi(os.Getenv(envReadyFD))
+	if err != nil {
+		return
+	}
+	_ = os.Unsetenv(envReadyFD)
+	f := os.NewFile(uintptr(fd), "ready")
+	_, _ = f.Write([]byte{1})
+	_ = f.Close()
+})
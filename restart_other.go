@@ -0,0 +1,8 @@
+//go:build !unix
+
+package web
+
+import "net"
+
+// setNonblock 非 unix 系统不支持传递监听，无需处理
+func setNonblock(net.Listener) {}
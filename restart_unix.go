@@ -0,0 +1,25 @@
+//go:build unix
+
+package web
+
+import (
+	"net"
+	"syscall"
+)
+
+// setNonblock 恢复监听的非阻塞模式
+// 传递给子进程时 exec 会调用 File.Fd，与监听共享的文件描述符会被设置为阻塞模式，
+// 此后 Accept 会阻塞在系统调用中，关闭监听也无法唤醒
+func setNonblock(l net.Listener) {
+	sc, ok := l.(syscall.Conn)
+	if !ok {
+		return
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return
+	}
+	_ = raw.Control(func(fd uintptr) {
+		_ = syscall.SetNonblock(int(fd), true)
+	})
+}
@@ -0,0 +1,106 @@
+//go:build unix
+
+package web
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restartChildAddr 重启后的子进程监听的地址
+const restartChildAddr = "WEB_TEST_RESTART_ADDR"
+
+func TestEngine_Restart(t *testing.T) {
+	if addr := os.Getenv(restartChildAddr); addr != "" {
+		runRestartedChild(t, addr)
+		return
+	}
+
+	e := NewEngine(WithShutdownSignals(), WithRestartCommand(os.Args[0], "-test.run=^TestEngine_Restart$"))
+	e.GET("/pid", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, strconv.Itoa(os.Getpid()))
+	})
+	arrived := make(chan struct{})
+	e.GET("/slow", func(ctx *Context) {
+		close(arrived)
+		time.Sleep(300 * time.Millisecond)
+		_ = ctx.String(http.StatusOK, "slow done")
+	})
+	go func() {
+		_ = e.Start("127.0.0.1:0")
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	base := "http://" + e.Addr().String()
+	t.Setenv(restartChildAddr, e.Addr().String())
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	slow := make(chan string, 1)
+	go func() {
+		slow <- getBody(t, client, base+"/slow")
+	}()
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, e.Restart(ctx))
+	assert.False(t, e.Ready())
+	// 进行中的请求处理完成
+	assert.Equal(t, "slow done", <-slow)
+
+	// 新的请求由子进程处理
+	pid := getBody(t, client, base+"/pid")
+	assert.NotEqual(t, strconv.Itoa(os.Getpid()), pid)
+	assert.Equal(t, "bye", getBody(t, client, base+"/quit"))
+}
+
+// runRestartedChild 作为重启后的子进程运行
+func runRestartedChild(t *testing.T, addr string) {
+	e := NewEngine(WithShutdownSignals())
+	e.GET("/pid", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, strconv.Itoa(os.Getpid()))
+	})
+	e.GET("/quit", func(ctx *Context) {
+		go func() {
+			_ = e.Shutdown(context.Background())
+		}()
+		_ = ctx.String(http.StatusOK, "bye")
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, e.Run(ctx, addr))
+}
+
+func TestEngine_RestartNotReady(t *testing.T) {
+	falsePath, err := exec.LookPath("false")
+	if err != nil {
+		t.Skip("false not found")
+	}
+	e := NewEngine(WithShutdownSignals(), WithRestartCommand(falsePath))
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "still here")
+	})
+	// 没有监听时无法重启
+	assert.Error(t, e.Restart(context.Background()))
+
+	go func() {
+		_ = e.Start("127.0.0.1:0")
+	}()
+	defer func() {
+		_ = e.Shutdown(context.Background())
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, e.Restart(ctx), ErrRestartNotReady)
+	assert.True(t, e.Ready())
+	assert.Equal(t, "still here", getBody(t, http.DefaultClient, "http://"+e.Addr().String()+"/"))
+}
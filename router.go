@@ -16,6 +16,7 @@ type node struct {
 	startChild *node        //通配符节点
 	paramChild *node        //路径参数节点
 	handlers   []HandleFunc //处理函数列表
+	doc        *routeDoc    // 从 Doc 和 Typed 处理函数中收集的文档信息
 }
 
 // matchInfo 匹配到的节点信息以及路径参数
@@ -59,6 +60,7 @@ func (r *router) addRoute(method string, path string, handlers ...HandleFunc) {
 	}
 	root.route = path
 	root.handlers = append(root.handlers, handlers...)
+	root.doc = describeRoute(root.handlers)
 }
 
 // findRoute 根据请求方法和路径查找匹配的路由
@@ -170,3 +172,26 @@ func (n *node) childOf(seg string) (*node, bool, bool) {
 	}
 	return n.startChild, false, n.startChild != nil
 }
+
+// walk 遍历所有注册了处理函数的节点
+func (r *router) walk(fn func(method string, n *node)) {
+	for method, root := range r.trees {
+		root.walk(method, fn)
+	}
+}
+
+// walk 深度优先遍历节点及其子节点
+func (n *node) walk(method string, fn func(method string, n *node)) {
+	if n.handlers != nil {
+		fn(method, n)
+	}
+	for _, child := range n.children {
+		child.walk(method, fn)
+	}
+	if n.paramChild != nil {
+		n.paramChild.walk(method, fn)
+	}
+	if n.startChild != nil {
+		n.startChild.walk(method, fn)
+	}
+}
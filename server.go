@@ -1,8 +1,18 @@
 package web
 
 import (
+	"errors"
+	"fmt"
+	"html/template"
 	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // HandleFunc 路由处理函数
@@ -22,14 +32,36 @@ type EngineOption func(*Engine)
 
 // Engine 实现了 Server 接口
 type Engine struct {
-	*router                              //继承路由
-	RouterGroup                          //包含默认路由组
-	NotFoundHandler HandleFunc           // 404 处理函数
-	AfterStart      func(l net.Listener) // 启动后回调
+	*router                                      //继承路由
+	RouterGroup                                  //包含默认路由组
+	NotFoundHandler         HandleFunc           // 404 处理函数
+	MethodNotAllowedHandler HandleFunc           // 405 处理函数
+	AfterStart              func(l net.Listener) // 启动后回调
+
+	templateEngine TemplateEngine    // 模板引擎
+	routeNames     map[string]string // 路由名 -> 路由路径
+	pool           sync.Pool         // 复用 Context
+	trustedProxies []netip.Prefix    // 受信任的代理
+	upload         uploadConfig      // 上传限制
+	maxBodyBytes   int64             // 请求体最大字节数，0 表示不限制
+	bindOptions    []BindOption      // 默认的 JSON 解析选项
+	validator      Validator         // Bind 使用的校验器
+	errorHandler   ErrorHandler      // 统一的错误处理函数
+
+	redirectAllowList      []string // 允许重定向到的外部主机
+	problemDetails         bool     // 内置错误是否以 problem+json 响应
+	handleMethodNotAllowed bool     // 请求方法不匹配时是否返回 405
+
+	lifecycle     *lifecycle     // 托管的 http.Server 及生命周期钩子
+	introspection *introspection // 内省配置，为空时不开启
 }
 
 // DefaultNotFoundHandler 默认的404页面处理函数
 var DefaultNotFoundHandler = func(ctx *Context) {
+	if ctx.useProblem() {
+		_ = ctx.Problem(NewProblem(http.StatusNotFound, "no route matches "+ctx.Req.URL.Path))
+		return
+	}
 	ctx.StatusCode = http.StatusNotFound
 	ctx.RespData = []byte("404 page not found")
 }
@@ -41,9 +73,18 @@ func NewEngine(opts ...EngineOption) *Engine {
 		RouterGroup: RouterGroup{
 			basePath: "/",
 		},
-		NotFoundHandler: DefaultNotFoundHandler,
+		NotFoundHandler:         DefaultNotFoundHandler,
+		MethodNotAllowedHandler: DefaultMethodNotAllowedHandler,
+		routeNames:              make(map[string]string),
+		lifecycle:               newLifecycle(),
+		upload: uploadConfig{
+			maxMemory: defaultMultipartMemory,
+		},
 	}
 	res.RouterGroup.engine = res
+	res.pool.New = func() any {
+		return newContext(nil, nil)
+	}
 	for _, opt := range opts {
 		opt(res)
 	}
@@ -64,24 +105,54 @@ func WithAfterStart(h func(l net.Listener)) EngineOption {
 	}
 }
 
+// WithTemplateEngine 设置引擎的模板引擎，并注册 url 模板函数用于反向生成路由地址
+func WithTemplateEngine(t TemplateEngine) EngineOption {
+	return func(e *Engine) {
+		e.templateEngine = t
+		t.Funcs(template.FuncMap{
+			"url": e.URL,
+		})
+	}
+}
+
 // ServeHTTP 实现了http.Handler接口的ServeHTTP方法
 func (e *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	ctx := newContext(writer, request)
+	ctx := e.pool.Get().(*Context)
+	ctx.reset(writer, request)
+	ctx.engine = e
 	e.serve(ctx)
+	// 请求处理结束后回收 Context，释放对请求和响应的引用
+	ctx.reset(nil, nil)
+	e.pool.Put(ctx)
 }
 
 // serve 处理请求的核心方法
 func (e *Engine) serve(ctx *Context) {
+	// 限制请求体大小，路由可以通过 BodyLimitBuilder 覆盖
+	ctx.wrapBody(e.maxBodyBytes)
 	// 查找路由，如果未找到则调用默认的404处理函数，否则执行对应的处理函数链
 	info, ok := e.findRoute(ctx.Req.Method, ctx.Req.URL.Path)
 	if !ok || info.node.handlers == nil {
-		e.NotFoundHandler(ctx)
+		var allowed []string
+		if e.handleMethodNotAllowed {
+			allowed = e.allowedMethods(ctx.Req.URL.Path)
+		}
+		if len(allowed) > 0 {
+			ctx.Resp.Header().Set("Allow", strings.Join(allowed, ", "))
+			e.MethodNotAllowedHandler(ctx)
+		} else {
+			e.NotFoundHandler(ctx)
+		}
 	} else {
 		ctx.MatchedRoute = info.node.route
 		ctx.PathParams = info.pathParams
 		ctx.handlers = info.node.handlers
 		ctx.Next()
 	}
+	// 响应已直接写出或连接已被接管时不再发送HTTP响应
+	if ctx.written {
+		return
+	}
 	// 发送HTTP响应
 	e.flushResp(ctx)
 }
@@ -94,17 +165,16 @@ func (e *Engine) flushResp(ctx *Context) {
 	}
 }
 
-// Start 启动服务器
+// Start 启动服务器，调用 Shutdown 优雅关闭后返回 nil
 func (e *Engine) Start(addr string) error {
-	l, err := net.Listen("tcp", addr)
-	if err != nil {
+	if err := e.introspectIfRequested(); err != nil {
 		return err
 	}
-	// 这里可以执行after start的操作
-	if e.AfterStart != nil {
-		e.AfterStart(l)
+	l, err := listen("tcp", addr)
+	if err != nil {
+		return err
 	}
-	return http.Serve(l, e)
+	return e.serveListener(l, false)
 }
 
 // Handle 注册路由处理函数
@@ -114,3 +184,115 @@ func (e *Engine) Handle(method string, path string, handlers ...HandleFunc) {
 	}
 	e.router.addRoute(method, path, handlers...)
 }
+
+// NameRoute 为路由路径命名，用于 URL 反向生成地址
+func (e *Engine) NameRoute(name string, path string) {
+	if _, ok := e.routeNames[name]; ok {
+		panic("duplicated route name")
+	}
+	e.routeNames[name] = path
+}
+
+// RouteInfo 已注册的路由信息
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Name    string `json:"name,omitempty"`    // 通过 NameRoute 设置的路由名
+	Handler string `json:"handler,omitempty"` // 最后一个处理函数的函数名
+}
+
+// Routes 返回所有已注册的路由，按路径和请求方法排序
+func (e *Engine) Routes() []RouteInfo {
+	names := make(map[string]string, len(e.routeNames))
+	for name, path := range e.routeNames {
+		names[path] = name
+	}
+	var res []RouteInfo
+	e.walk(func(method string, n *node) {
+		res = append(res, RouteInfo{
+			Method:  method,
+			Path:    n.route,
+			Name:    names[n.route],
+			Handler: funcName(n.handlers[len(n.handlers)-1]),
+		})
+	})
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Path != res[j].Path {
+			return res[i].Path < res[j].Path
+		}
+		return res[i].Method < res[j].Method
+	})
+	return res
+}
+
+// funcName 返回函数名
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return ""
+	}
+	return f.Name()
+}
+
+// WrapHandler 将 http.Handler 转换为 HandleFunc，响应由 h 直接写出
+func WrapHandler(h http.Handler) HandleFunc {
+	return func(ctx *Context) {
+		ctx.written = true
+		h.ServeHTTP(ctx.Resp, ctx.Req)
+	}
+}
+
+// URL 根据路由名反向生成地址，pairs 为交替出现的参数名和参数值
+// 路径中没有用到的参数会作为查询参数拼接在地址后面，通配符参数的名字为 "*"
+// name 以 / 开头时直接作为路由路径使用
+func (e *Engine) URL(name string, pairs ...any) (string, error) {
+	pattern := name
+	if !strings.HasPrefix(name, "/") {
+		var ok bool
+		pattern, ok = e.routeNames[name]
+		if !ok {
+			return "", fmt.Errorf("route name %q not found", name)
+		}
+	}
+	if len(pairs)%2 != 0 {
+		return "", errors.New("url params must be key-value pairs")
+	}
+	params := make(map[string]string, len(pairs)/2)
+	keys := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key := fmt.Sprint(pairs[i])
+		params[key] = fmt.Sprint(pairs[i+1])
+		keys = append(keys, key)
+	}
+
+	segs := strings.Split(pattern, "/")
+	for i, seg := range segs {
+		var key string
+		switch {
+		case seg == "*":
+			key = "*"
+		case strings.HasPrefix(seg, ":"):
+			key = seg[1:]
+		default:
+			continue
+		}
+		val, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("missing url param %q for route %q", key, pattern)
+		}
+		segs[i] = url.PathEscape(val)
+		delete(params, key)
+	}
+
+	res := strings.Join(segs, "/")
+	query := url.Values{}
+	for _, key := range keys {
+		if val, ok := params[key]; ok {
+			query.Set(key, val)
+		}
+	}
+	if len(query) > 0 {
+		res += "?" + query.Encode()
+	}
+	return res, nil
+}
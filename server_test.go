@@ -9,3 +9,54 @@ func TestServer(t *testing.T) {
 	var h Server
 	http.ListenAndServe(":8080", h)
 }
+
+func BenchmarkEngine_ServeHTTP(b *testing.B) {
+	e := NewEngine()
+	e.GET("/user/:id", func(ctx *Context) {
+		ctx.Set("user", ctx.Param("id"))
+		_, _ = ctx.QueryValue("page")
+		ctx.Status(http.StatusOK)
+	})
+	req, err := http.NewRequest(http.MethodGet, "/user/12?page=1", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	writer := &MockWriter{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.ServeHTTP(writer, req)
+	}
+}
+
+// BenchmarkContext_Pool 对比复用 Context 与每个请求创建新的 Context
+func BenchmarkContext_Pool(b *testing.B) {
+	e := NewEngine()
+	handler := func(ctx *Context) {
+		ctx.Set("user", ctx.Param("id"))
+		_, _ = ctx.QueryValue("page")
+		ctx.Status(http.StatusOK)
+	}
+	e.GET("/user/:id", handler)
+	req, err := http.NewRequest(http.MethodGet, "/user/12?page=1&size=20", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	writer := &MockWriter{}
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			e.ServeHTTP(writer, req)
+		}
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ctx := e.NewContext(writer, req)
+			ctx.PathParams = map[string]string{"id": "12"}
+			e.HandleContext(ctx, handler)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// defaultReadHeaderTimeout 默认的读取请求头超时时间，防止 slowloris 攻击
+const defaultReadHeaderTimeout = 10 * time.Second
+
+// WithReadTimeout 设置读取整个请求(包括请求体)的超时时间
+func WithReadTimeout(timeout time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.Server().ReadTimeout = timeout
+	}
+}
+
+// WithReadHeaderTimeout 设置读取请求头的超时时间，默认为 10 秒
+func WithReadHeaderTimeout(timeout time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.Server().ReadHeaderTimeout = timeout
+	}
+}
+
+// WithWriteTimeout 设置写响应的超时时间
+func WithWriteTimeout(timeout time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.Server().WriteTimeout = timeout
+	}
+}
+
+// WithIdleTimeout 设置 keep-alive 连接等待下一个请求的超时时间
+func WithIdleTimeout(timeout time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.Server().IdleTimeout = timeout
+	}
+}
+
+// WithMaxHeaderBytes 设置请求头的最大字节数
+func WithMaxHeaderBytes(n int) EngineOption {
+	return func(e *Engine) {
+		e.Server().MaxHeaderBytes = n
+	}
+}
+
+// WithKeepAlives 设置是否开启 HTTP keep-alive，默认开启
+func WithKeepAlives(enabled bool) EngineOption {
+	return func(e *Engine) {
+		e.Server().SetKeepAlivesEnabled(enabled)
+	}
+}
+
+// WithConnState 设置连接状态变化时的回调
+func WithConnState(fn func(conn net.Conn, state http.ConnState)) EngineOption {
+	return func(e *Engine) {
+		e.Server()
+		e.lifecycle.connState = fn
+	}
+}
+
+// WithServerConfig 直接修改底层的 http.Server，用于其他可选项没有覆盖的配置
+// Handler 会被忽略，始终为 Engine 本身
+func WithServerConfig(fn func(srv *http.Server)) EngineOption {
+	return func(e *Engine) {
+		srv := e.Server()
+		lc := e.lifecycle
+		// fn 看到的是用户设置的回调，引擎仍然通过 trackConn 统计连接数
+		srv.ConnState = lc.connState
+		fn(srv)
+		lc.connState = srv.ConnState
+		srv.ConnState = lc.trackConn
+		srv.Handler = e
+	}
+}
+
+// trackConn 统计活跃连接数，然后调用用户设置的回调
+func (l *lifecycle) trackConn(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		l.activeConns.Add(1)
+	case http.StateClosed, http.StateHijacked:
+		l.activeConns.Add(-1)
+	}
+	if l.connState != nil {
+		l.connState(conn, state)
+	}
+}
+
+// ActiveConns 返回当前的连接数，被接管的连接(例如 websocket、h2c)不计入
+func (e *Engine) ActiveConns() int64 {
+	return e.lifecycle.activeConns.Load()
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_ServerOptions(t *testing.T) {
+	connState := func(conn net.Conn, state http.ConnState) {}
+	e := NewEngine(
+		WithReadTimeout(time.Second),
+		WithWriteTimeout(2*time.Second),
+		WithIdleTimeout(3*time.Second),
+		WithMaxHeaderBytes(4096),
+		WithConnState(connState),
+		WithServerConfig(func(srv *http.Server) {
+			srv.ErrorLog = nil
+			srv.Handler = nil
+		}),
+	)
+	srv := e.Server()
+	assert.Equal(t, time.Second, srv.ReadTimeout)
+	assert.Equal(t, defaultReadHeaderTimeout, srv.ReadHeaderTimeout)
+	assert.Equal(t, 2*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 3*time.Second, srv.IdleTimeout)
+	assert.Equal(t, 4096, srv.MaxHeaderBytes)
+	assert.NotNil(t, srv.ConnState)
+	assert.Equal(t, e, srv.Handler)
+}
+
+func TestEngine_ReadHeaderTimeout(t *testing.T) {
+	var active atomic.Int32
+	e := NewEngine(
+		WithReadHeaderTimeout(100*time.Millisecond),
+		WithKeepAlives(false),
+		WithConnState(func(conn net.Conn, state http.ConnState) {
+			switch state {
+			case http.StateNew:
+				active.Add(1)
+			case http.StateClosed, http.StateHijacked:
+				active.Add(-1)
+			}
+		}),
+	)
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "ok")
+	})
+	go func() {
+		_ = e.Start("127.0.0.1:0")
+	}()
+	defer func() {
+		_ = e.Shutdown(context.Background())
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	// 慢速发送请求头的连接会被关闭
+	conn, err := net.Dial("tcp", e.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	start := time.Now()
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Eventually(t, func() bool {
+		return active.Load() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// 关闭 keep-alive 后响应带有 Connection: close
+	resp, err := http.Get("http://" + e.Addr().String() + "/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.True(t, resp.Close)
+}
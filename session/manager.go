@@ -24,7 +24,7 @@ func (m *Manager) GetSession(ctx *web.Context) (Session, error) {
 	if err != nil {
 		return nil, err
 	}
-	res, err := m.Store.Get(ctx.Req.Context(), sessId)
+	res, err := m.Store.Get(ctx, sessId)
 	if err != nil {
 		return nil, err
 	}
@@ -36,9 +36,9 @@ func (m *Manager) GetSession(ctx *web.Context) (Session, error) {
 func (m *Manager) InitSession(ctx *web.Context, sessId string) (Session, error) {
 	existId, err := m.Propagator.Extract(ctx.Req)
 	if err == nil {
-		_ = m.Store.Remove(ctx.Req.Context(), existId)
+		_ = m.Store.Remove(ctx, existId)
 	}
-	sess, err := m.Store.Generate(ctx.Req.Context(), sessId)
+	sess, err := m.Store.Generate(ctx, sessId)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +54,7 @@ func (m *Manager) RemoveSession(ctx *web.Context) error {
 	if err != nil {
 		return err
 	}
-	err = m.Store.Remove(ctx.Req.Context(), sess.ID())
+	err = m.Store.Remove(ctx, sess.ID())
 	if err != nil {
 		return err
 	}
@@ -69,12 +69,12 @@ func (m *Manager) RefreshSession(ctx *web.Context) error {
 	if err != nil {
 		return err
 	}
-	return m.Store.Refresh(ctx.Req.Context(), sess.ID())
+	return m.Store.Refresh(ctx, sess.ID())
 }
 
 // SaveSession 将会话保存到存储中，并更新上下文中的会话信息。
 func (m *Manager) SaveSession(ctx *web.Context, sess Session) error {
-	err := m.Store.Set(ctx.Req.Context(), sess)
+	err := m.Store.Set(ctx, sess)
 	if err != nil {
 		return err
 	}
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"github.com/Andras5014/go-web"
 	"github.com/redis/go-redis/v9"
 	"reflect"
 	"time"
 )
 
+var _ web.Checker = &RedisStore{}
+
 type RedisStore struct {
 	cmd            redis.Cmdable
 	exp            time.Duration
@@ -107,3 +110,8 @@ func (r *RedisStore) Remove(ctx context.Context, id string) error {
 func (r *RedisStore) Refresh(ctx context.Context, id string) error {
 	return r.cmd.Expire(ctx, id, r.exp).Err()
 }
+
+// Check 检查 Redis 是否可用，可以作为就绪检查注册到 web.Health
+func (r *RedisStore) Check(ctx context.Context) error {
+	return r.cmd.Ping(ctx).Err()
+}
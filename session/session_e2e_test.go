@@ -73,3 +73,15 @@ func TestNeedSession(t *testing.T) {
 
 	e.Start(":8080")
 }
+
+func TestRedisStore_Check(t *testing.T) {
+	store := NewRedisStore(redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	}), time.Minute)
+	require.NoError(t, store.Check(context.Background()))
+
+	unreachable := NewRedisStore(redis.NewClient(&redis.Options{
+		Addr: "localhost:1",
+	}), time.Minute)
+	require.Error(t, unreachable.Check(context.Background()))
+}
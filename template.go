@@ -0,0 +1,253 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// TemplateEngine 模板引擎接口
+type TemplateEngine interface {
+	// Render 渲染名为 name 的模板
+	Render(w io.Writer, name string, data any) error
+	// Funcs 注册模板函数，需要在第一次渲染前调用
+	Funcs(funcs template.FuncMap)
+}
+
+var _ TemplateEngine = &GoTemplateEngine{}
+
+// TemplateOption 模板引擎的可选项
+type TemplateOption func(t *GoTemplateEngine)
+
+// GoTemplateEngine 基于 html/template 的模板引擎
+// 布局(layouts)和局部模板(partials)在所有页面之间共享，每个页面单独编译，
+// 因此不同页面可以对同一个 block 给出各自的定义
+type GoTemplateEngine struct {
+	fsys     fs.FS            // 模板文件所在的文件系统
+	pages    []string         // 页面模板的 glob 模式
+	layouts  []string         // 布局模板的 glob 模式
+	partials []string         // 局部模板的 glob 模式
+	funcs    template.FuncMap // 自定义模板函数
+	devMode  bool             // 开发模式，模板文件变化时自动重新加载
+
+	mu        sync.RWMutex
+	loaded    bool
+	base      *template.Template            // 布局和局部模板
+	templates map[string]*template.Template // 页面名 -> 编译后的模板
+	modTimes  map[string]time.Time          // 文件 -> 加载时的修改时间
+}
+
+// NewTemplateEngine 创建基于 html/template 的模板引擎
+func NewTemplateEngine(opts ...TemplateOption) *GoTemplateEngine {
+	res := &GoTemplateEngine{
+		layouts:  []string{"layouts/*.html"},
+		partials: []string{"partials/*.html"},
+		funcs:    template.FuncMap{},
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// WithTemplateFS 从 fs.FS(例如 embed.FS)中加载匹配 patterns 的页面模板
+func WithTemplateFS(fsys fs.FS, patterns ...string) TemplateOption {
+	return func(t *GoTemplateEngine) {
+		t.fsys = fsys
+		t.pages = patterns
+	}
+}
+
+// WithTemplateDir 从磁盘目录 dir 中加载匹配 patterns 的页面模板
+func WithTemplateDir(dir string, patterns ...string) TemplateOption {
+	return WithTemplateFS(os.DirFS(dir), patterns...)
+}
+
+// WithLayouts 设置布局模板的 glob 模式，默认为 layouts/*.html
+func WithLayouts(patterns ...string) TemplateOption {
+	return func(t *GoTemplateEngine) {
+		t.layouts = patterns
+	}
+}
+
+// WithPartials 设置局部模板的 glob 模式，默认为 partials/*.html
+func WithPartials(patterns ...string) TemplateOption {
+	return func(t *GoTemplateEngine) {
+		t.partials = patterns
+	}
+}
+
+// WithFuncMap 注册自定义模板函数
+func WithFuncMap(funcs template.FuncMap) TemplateOption {
+	return func(t *GoTemplateEngine) {
+		t.Funcs(funcs)
+	}
+}
+
+// WithDevMode 开启开发模式，每次渲染前检查模板文件是否变化并重新加载
+func WithDevMode(dev bool) TemplateOption {
+	return func(t *GoTemplateEngine) {
+		t.devMode = dev
+	}
+}
+
+// Funcs 注册模板函数
+func (t *GoTemplateEngine) Funcs(funcs template.FuncMap) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, fn := range funcs {
+		t.funcs[name] = fn
+	}
+	t.loaded = false
+}
+
+// Load 加载并编译所有模板，可以在启动时调用以尽早发现模板错误
+func (t *GoTemplateEngine) Load() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.load()
+}
+
+// load 加载模板，调用方需要持有写锁
+func (t *GoTemplateEngine) load() error {
+	if t.fsys == nil {
+		return errors.New("template: no template files configured")
+	}
+	shared, err := t.glob(append(append([]string{}, t.layouts...), t.partials...))
+	if err != nil {
+		return err
+	}
+	pages, err := t.glob(t.pages)
+	if err != nil {
+		return err
+	}
+	modTimes := make(map[string]time.Time, len(shared)+len(pages))
+
+	base := template.New("").Funcs(t.funcs)
+	isShared := make(map[string]bool, len(shared))
+	for _, name := range shared {
+		isShared[name] = true
+		if err = t.parseFile(base, name, modTimes); err != nil {
+			return err
+		}
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, name := range pages {
+		if isShared[name] {
+			continue
+		}
+		tpl, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		if err = t.parseFile(tpl, name, modTimes); err != nil {
+			return err
+		}
+		templates[name] = tpl
+	}
+
+	t.base = base
+	t.templates = templates
+	t.modTimes = modTimes
+	t.loaded = true
+	return nil
+}
+
+// parseFile 将文件以其相对路径为名解析到 tpl 中
+func (t *GoTemplateEngine) parseFile(tpl *template.Template, name string, modTimes map[string]time.Time) error {
+	data, err := fs.ReadFile(t.fsys, name)
+	if err != nil {
+		return err
+	}
+	if _, err = tpl.New(name).Parse(string(data)); err != nil {
+		return err
+	}
+	if info, err := fs.Stat(t.fsys, name); err == nil {
+		modTimes[name] = info.ModTime()
+	}
+	return nil
+}
+
+// glob 返回匹配 patterns 的文件，结果去重并排序
+func (t *GoTemplateEngine) glob(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var res []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(t.fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				res = append(res, m)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// changed 判断模板文件是否有新增、删除或修改
+func (t *GoTemplateEngine) changed() bool {
+	files, err := t.glob(append(append(append([]string{}, t.layouts...), t.partials...), t.pages...))
+	if err != nil || len(files) != len(t.modTimes) {
+		return true
+	}
+	for _, name := range files {
+		info, err := fs.Stat(t.fsys, name)
+		if err != nil {
+			return true
+		}
+		if modTime, ok := t.modTimes[name]; !ok || !modTime.Equal(info.ModTime()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render 渲染名为 name 的模板，name 为模板文件相对于文件系统根目录的路径
+// 也可以是布局或局部模板中 define 的模板名
+func (t *GoTemplateEngine) Render(w io.Writer, name string, data any) error {
+	tpl, err := t.lookup(name)
+	if err != nil {
+		return err
+	}
+	return tpl.ExecuteTemplate(w, name, data)
+}
+
+// lookup 查找模板，必要时(首次渲染或开发模式下文件变化)重新加载
+func (t *GoTemplateEngine) lookup(name string) (*template.Template, error) {
+	t.mu.RLock()
+	reload := !t.loaded || (t.devMode && t.changed())
+	t.mu.RUnlock()
+	if reload {
+		t.mu.Lock()
+		if !t.loaded || (t.devMode && t.changed()) {
+			if err := t.load(); err != nil {
+				t.mu.Unlock()
+				return nil, err
+			}
+		}
+		t.mu.Unlock()
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if tpl, ok := t.templates[name]; ok {
+		return tpl, nil
+	}
+	if t.base.Lookup(name) != nil {
+		return t.base, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_Render(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{Data: []byte(
+			`{{define "base"}}<html><title>{{block "title" .}}default{{end}}</title><body>{{template "content" .}}{{template "footer" .}}</body></html>{{end}}`)},
+		"partials/footer.html": &fstest.MapFile{Data: []byte(`{{define "footer"}}<footer>{{upper "go-web"}}</footer>{{end}}`)},
+		"pages/user.html": &fstest.MapFile{Data: []byte(
+			`{{template "base" .}}{{define "title"}}user{{end}}{{define "content"}}<a href="{{url "user.detail" "id" .ID "tab" "posts"}}">{{.Name}}</a>{{end}}`)},
+		"pages/home.html": &fstest.MapFile{Data: []byte(
+			`{{template "base" .}}{{define "content"}}<p>{{.}}</p>{{end}}`)},
+	}
+	tpl := NewTemplateEngine(
+		WithTemplateFS(fsys, "pages/*.html"),
+		WithFuncMap(template.FuncMap{"upper": strings.ToUpper}),
+	)
+	e := NewEngine(WithTemplateEngine(tpl))
+	e.GET("/user/:id", func(ctx *Context) {
+		_ = ctx.Render(http.StatusOK, "pages/user.html", map[string]any{
+			"ID":   ctx.Param("id"),
+			"Name": "<tom>",
+		})
+	})
+	e.NameRoute("user.detail", "/user/:id")
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.Render(http.StatusOK, "pages/home.html", "home")
+	})
+	e.GET("/missing", func(ctx *Context) {
+		if err := ctx.Render(http.StatusOK, "pages/missing.html", nil); err != nil {
+			ctx.Status(http.StatusInternalServerError)
+		}
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/user/12", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t,
+		`<html><title>user</title><body><a href="/user/12?tab=posts">&lt;tom&gt;</a><footer>GO-WEB</footer></body></html>`,
+		recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t,
+		`<html><title>default</title><body><p>home</p><footer>GO-WEB</footer></body></html>`,
+		recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestGoTemplateEngine_DevMode(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "index.html")
+	require.NoError(t, os.WriteFile(page, []byte(`v1 {{.}}`), 0o644))
+
+	tpl := NewTemplateEngine(WithTemplateDir(dir, "*.html"), WithDevMode(true))
+	require.NoError(t, tpl.Load())
+
+	var sb strings.Builder
+	require.NoError(t, tpl.Render(&sb, "index.html", "go-web"))
+	assert.Equal(t, "v1 go-web", sb.String())
+
+	require.NoError(t, os.WriteFile(page, []byte(`v2 {{.}}`), 0o644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(page, later, later))
+	sb.Reset()
+	require.NoError(t, tpl.Render(&sb, "index.html", "go-web"))
+	assert.Equal(t, "v2 go-web", sb.String())
+
+	// 新增的模板文件也会被加载
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "about.html"), []byte(`about`), 0o644))
+	sb.Reset()
+	require.NoError(t, tpl.Render(&sb, "about.html", nil))
+	assert.Equal(t, "about", sb.String())
+}
+
+func TestEngine_URL(t *testing.T) {
+	e := NewEngine()
+	e.NameRoute("order", "/order/:id/detail")
+	e.NameRoute("static", "/static/*")
+
+	testCases := []struct {
+		name    string
+		route   string
+		pairs   []any
+		want    string
+		wantErr bool
+	}{
+		{name: "param", route: "order", pairs: []any{"id", 1}, want: "/order/1/detail"},
+		{name: "escape", route: "order", pairs: []any{"id", "a b"}, want: "/order/a%20b/detail"},
+		{name: "wildcard", route: "static", pairs: []any{"*", "app.js"}, want: "/static/app.js"},
+		{name: "pattern", route: "/user/:name", pairs: []any{"name", "tom", "page", 2}, want: "/user/tom?page=2"},
+		{name: "missing param", route: "order", wantErr: true},
+		{name: "unknown name", route: "unknown", wantErr: true},
+		{name: "odd pairs", route: "order", pairs: []any{"id"}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := e.URL(tc.route, tc.pairs...)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	assert.Panics(t, func() {
+		e.NameRoute("order", "/order")
+	})
+}
@@ -0,0 +1,171 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithTLSConfig 设置 HTTPS 使用的 tls.Config，会覆盖之前通过 WithClientAuth 设置的配置
+func WithTLSConfig(cfg *tls.Config) EngineOption {
+	return func(e *Engine) {
+		e.Server().TLSConfig = cfg.Clone()
+	}
+}
+
+// WithClientAuth 开启客户端证书校验(mTLS)，caFiles 为签发客户端证书的 CA 证书文件(PEM)
+// 证书文件无法读取或解析时 panic
+func WithClientAuth(auth tls.ClientAuthType, caFiles ...string) EngineOption {
+	return func(e *Engine) {
+		pool := x509.NewCertPool()
+		for _, file := range caFiles {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				panic(fmt.Sprintf("web: read client CA %s: %v", file, err))
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				panic(fmt.Sprintf("web: no certificate found in client CA %s", file))
+			}
+		}
+		cfg := e.tlsConfig()
+		cfg.ClientAuth = auth
+		cfg.ClientCAs = pool
+	}
+}
+
+// tlsConfig 返回 Server().TLSConfig，不存在时创建默认配置
+func (e *Engine) tlsConfig() *tls.Config {
+	srv := e.Server()
+	if srv.TLSConfig == nil {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return srv.TLSConfig
+}
+
+// StartTLS 在 addr 上启动 HTTPS 服务器
+// certFile 和 keyFile 不为空时从文件加载证书，文件修改后新的连接会自动使用新证书；
+// 为空时使用 WithTLSConfig 设置的证书
+func (e *Engine) StartTLS(addr string, certFile string, keyFile string) error {
+	if err := e.introspectIfRequested(); err != nil {
+		return err
+	}
+	l, err := e.listenTLS(addr, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	return e.serveListener(l, true)
+}
+
+// RunTLS 与 Run 相同，但提供 HTTPS 服务，证书的加载方式与 StartTLS 相同
+func (e *Engine) RunTLS(ctx context.Context, addr string, certFile string, keyFile string) error {
+	if err := e.introspectIfRequested(); err != nil {
+		return err
+	}
+	l, err := e.listenTLS(addr, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	return e.runListeners(ctx, []net.Listener{l}, true)
+}
+
+// listenTLS 配置证书后在 addr 上监听
+func (e *Engine) listenTLS(addr string, certFile string, keyFile string) (net.Listener, error) {
+	cfg := e.tlsConfig()
+	if certFile != "" || keyFile != "" {
+		reloader, err := newCertReloader(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetCertificate = reloader.GetCertificate
+	}
+	return listen("tcp", addr)
+}
+
+// certReloader 从文件加载证书，文件修改时间变化后重新加载
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certTime time.Time // 加载时证书文件的修改时间
+	keyTime  time.Time // 加载时私钥文件的修改时间
+}
+
+// newCertReloader 创建证书加载器并立即加载一次证书
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload 重新加载证书
+func (r *certReloader) reload() error {
+	certTime, keyTime, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.certTime = certTime
+	r.keyTime = keyTime
+	return nil
+}
+
+// modTimes 返回证书和私钥文件的修改时间
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// GetCertificate 实现 tls.Config.GetCertificate
+// 每次握手都会检查文件的修改时间，重新加载失败时(例如证书和私钥只更新了一个)继续使用旧证书
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certTime, keyTime, err := r.modTimes()
+	r.mu.RLock()
+	changed := err == nil && (!certTime.Equal(r.certTime) || !keyTime.Equal(r.keyTime))
+	r.mu.RUnlock()
+	if changed {
+		_ = r.reload()
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// ClientCertificate 返回经过校验的客户端证书，未使用 mTLS 或证书未经校验时返回 nil
+func (c *Context) ClientCertificate() *x509.Certificate {
+	if c.Req == nil || c.Req.TLS == nil || len(c.Req.TLS.VerifiedChains) == 0 ||
+		len(c.Req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return c.Req.TLS.VerifiedChains[0][0]
+}
+
+// PeerIdentity 返回经过校验的客户端证书的 Subject CommonName，没有时返回空字符串
+func (c *Context) PeerIdentity() string {
+	cert := c.ClientCertificate()
+	if cert == nil {
+		return ""
+	}
+	return cert.Subject.CommonName
+}
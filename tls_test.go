@@ -0,0 +1,233 @@
+package web
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert 测试用证书
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert 生成证书，parent 为 nil 时生成自签名的 CA 证书
+func newTestCert(t *testing.T, cn string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		tmpl.DNSNames = []string{"localhost"}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// writeFiles 将证书和私钥以 PEM 格式写入 dir
+func (c *testCert) writeFiles(t *testing.T, dir string, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// tlsCertificate 转换为 tls.Certificate
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func TestEngine_StartTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test ca", nil, 0)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+	server := newTestCert(t, "server v1", ca, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := server.writeFiles(t, dir, "server")
+	client := newTestCert(t, "alice", ca, x509.ExtKeyUsageClientAuth)
+
+	e := NewEngine(WithClientAuth(tls.VerifyClientCertIfGiven, caFile))
+	e.GET("/whoami", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, ctx.Scheme()+" "+ctx.PeerIdentity())
+	})
+	go func() {
+		_ = e.StartTLS("127.0.0.1:0", certFile, keyFile)
+	}()
+	defer func() {
+		_ = e.Shutdown(context.Background())
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	url := "https://" + e.Addr().String() + "/whoami"
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			ForceAttemptHTTP2: true,
+			DisableKeepAlives: true,
+		}}
+	}
+	get := func(c *http.Client) (*http.Response, string) {
+		resp, err := c.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// 没有客户端证书
+	resp, body := get(newClient())
+	assert.Equal(t, "https ", body)
+	assert.Equal(t, "server v1", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	// 客户端证书经过校验
+	_, body = get(newClient(client.tlsCertificate()))
+	assert.Equal(t, "https alice", body)
+
+	// 不受信任的客户端证书被拒绝
+	other := newTestCert(t, "other ca", nil, 0)
+	mallory := newTestCert(t, "mallory", other, x509.ExtKeyUsageClientAuth).tlsCertificate()
+	untrusted := newClient()
+	// 客户端默认只发送匹配服务端 CA 列表的证书，这里强制发送
+	untrusted.Transport.(*http.Transport).TLSClientConfig.GetClientCertificate =
+		func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &mallory, nil
+		}
+	_, err := untrusted.Get(url)
+	assert.Error(t, err)
+
+	// 证书文件更新后新的连接使用新证书
+	server2 := newTestCert(t, "server v2", ca, x509.ExtKeyUsageServerAuth)
+	server2.writeFiles(t, dir, "server")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	resp, _ = get(newClient())
+	assert.Equal(t, "server v2", resp.TLS.PeerCertificates[0].Subject.CommonName)
+}
+
+func TestEngine_StartTLSWithConfig(t *testing.T) {
+	ca := newTestCert(t, "test ca", nil, 0)
+	server := newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, "bob", ca, x509.ExtKeyUsageClientAuth)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	e := NewEngine(WithTLSConfig(&tls.Config{
+		Certificates: []tls.Certificate{server.tlsCertificate()},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}))
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, ctx.ClientCertificate().Subject.CommonName)
+	})
+	go func() {
+		_ = e.StartTLS("127.0.0.1:0", "", "")
+	}()
+	defer func() {
+		_ = e.Shutdown(context.Background())
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+	url := "https://" + e.Addr().String() + "/"
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certs},
+		}}
+	}
+	// 必须提供客户端证书
+	_, err := newClient().Get(url)
+	assert.Error(t, err)
+
+	resp, err := newClient(client.tlsCertificate()).Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", string(body))
+}
+
+func TestEngine_StartTLSInvalidCert(t *testing.T) {
+	e := NewEngine()
+	err := e.StartTLS("127.0.0.1:0", "not-exist.crt", "not-exist.key")
+	assert.Error(t, err)
+	assert.Panics(t, func() {
+		NewEngine(WithClientAuth(tls.RequireAndVerifyClientCert, "not-exist.crt"))
+	})
+}
+
+func TestEngine_RunTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test ca", nil, 0)
+	server := newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := server.writeFiles(t, dir, "server")
+
+	e := NewEngine()
+	e.GET("/", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, ctx.Scheme())
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- e.RunTLS(ctx, "127.0.0.1:0", certFile, keyFile)
+	}()
+	require.Eventually(t, e.Ready, time.Second, 10*time.Millisecond)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get("https://" + e.Addr().String() + "/")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "https", string(body))
+
+	cancel()
+	assert.NoError(t, <-runErr)
+	assert.False(t, e.Ready())
+
+	err = NewEngine().RunTLS(context.Background(), "127.0.0.1:0", "not-exist.crt", "not-exist.key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,331 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// paramSources 支持绑定的参数来源，同一字段有多个来源时按该顺序查找
+var paramSources = []string{SourcePath, SourceQuery, SourceHeader, SourceForm}
+
+// ErrUnsupportedMediaType 请求体的内容类型不支持绑定
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ErrorHandler 统一处理 Typed 处理函数、绑定和校验返回的错误
+type ErrorHandler func(ctx *Context, err error)
+
+// WithErrorHandler 设置引擎的错误处理函数，默认为 AbortWithError
+func WithErrorHandler(h ErrorHandler) EngineOption {
+	return func(e *Engine) {
+		e.errorHandler = h
+	}
+}
+
+// HandleError 中止请求处理，并使用引擎的错误处理函数处理 err
+func (c *Context) HandleError(err error) {
+	if c.engine != nil && c.engine.errorHandler != nil {
+		c.Abort()
+		c.engine.errorHandler(c, err)
+		return
+	}
+	c.AbortWithError(err)
+}
+
+// Validator 校验绑定后的请求，通常用于接入第三方校验库
+type Validator interface {
+	Validate(val any) error
+}
+
+// ValidatorFunc 函数形式的 Validator
+type ValidatorFunc func(val any) error
+
+func (f ValidatorFunc) Validate(val any) error {
+	return f(val)
+}
+
+// WithValidator 设置引擎的校验器，Bind 在请求自身的 Validate 方法之后调用
+func WithValidator(v Validator) EngineOption {
+	return func(e *Engine) {
+		e.validator = v
+	}
+}
+
+// Validatable 可以自我校验的请求类型
+type Validatable interface {
+	Validate() error
+}
+
+var _ StatusCoder = &ValidationError{}
+
+// ValidationError 请求校验失败的错误
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "validation: " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode 校验失败返回 422
+func (e *ValidationError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// Bind 将请求绑定到 val 并校验，val 必须是非 nil 指针
+// 请求体按 Content-Type 以 JSON 或表单解析，然后按字段的 path、query、header、form 标签绑定参数，
+// 参数会覆盖请求体中的同名字段；没有值的字段使用 default 标签的值
+// 校验依次调用 val 的 Validate 方法和引擎的 Validator，失败时返回 *ValidationError
+func (c *Context) Bind(val any) error {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("bind target must be a non-nil pointer")
+	}
+	form, err := c.bindBody(val)
+	if err != nil {
+		return err
+	}
+	if elem := rv.Elem(); elem.Kind() == reflect.Struct {
+		if err = c.bindParams(elem, form); err != nil {
+			return err
+		}
+	}
+	if v, ok := val.(Validatable); ok {
+		if err = v.Validate(); err != nil {
+			return &ValidationError{Err: err}
+		}
+	}
+	if c.engine != nil && c.engine.validator != nil {
+		if err = c.engine.validator.Validate(val); err != nil {
+			return &ValidationError{Err: err}
+		}
+	}
+	return nil
+}
+
+// hasBody 判断请求是否带有请求体
+func (c *Context) hasBody() bool {
+	return c.Req.Body != nil && c.Req.Body != http.NoBody && c.Req.ContentLength != 0
+}
+
+// bindBody 解析请求体，JSON 直接解析到 val，表单返回表单值用于绑定 form 标签
+func (c *Context) bindBody(val any) (url.Values, error) {
+	if !c.hasBody() {
+		return nil, nil
+	}
+	contentType := c.GetHeader("Content-Type")
+	mediaType := ""
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, &BindError{Err: err}
+		}
+	}
+	switch {
+	case mediaType == "" || mediaType == MIMEJSON || strings.HasSuffix(mediaType, "+json"):
+		err := c.BindJSON(val)
+		// 长度未知的请求体可能为空
+		if errors.Is(err, io.EOF) && c.Req.ContentLength < 0 {
+			return nil, nil
+		}
+		return nil, err
+	case mediaType == "application/x-www-form-urlencoded":
+		if err := c.Req.ParseForm(); err != nil {
+			return nil, bodyErr(err)
+		}
+		return c.Req.PostForm, nil
+	case mediaType == "multipart/form-data":
+		form, err := c.MultipartForm()
+		if err != nil {
+			return nil, err
+		}
+		return form.Value, nil
+	default:
+		return nil, &BindError{Err: ErrUnsupportedMediaType}
+	}
+}
+
+// bindField 需要绑定参数的字段
+type bindField struct {
+	index  []int             // 字段在结构体中的位置
+	names  map[string]string // 参数来源 -> 参数名
+	def    string            // 默认值
+	hasDef bool              // 是否设置了 default 标签
+}
+
+// bindFieldCache 结构体类型 -> []bindField
+var bindFieldCache sync.Map
+
+// bindFields 返回结构体中需要绑定参数的字段，结果按类型缓存
+func bindFields(typ reflect.Type) []bindField {
+	if res, ok := bindFieldCache.Load(typ); ok {
+		return res.([]bindField)
+	}
+	res := collectBindFields(typ, nil)
+	bindFieldCache.Store(typ, res)
+	return res
+}
+
+// collectBindFields 收集结构体中带有参数标签的字段，展开没有标签的嵌入结构体
+func collectBindFields(typ reflect.Type, index []int) []bindField {
+	var res []bindField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+		names := make(map[string]string)
+		for _, source := range paramSources {
+			if name, ok := f.Tag.Lookup(source); ok && name != "" && name != "-" {
+				names[source] = name
+			}
+		}
+		if len(names) == 0 {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				res = append(res, collectBindFields(f.Type, fieldIndex)...)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		def, hasDef := f.Tag.Lookup("default")
+		res = append(res, bindField{index: fieldIndex, names: names, def: def, hasDef: hasDef})
+	}
+	return res
+}
+
+// paramValues 按来源获取参数值
+func (c *Context) paramValues(source, name string, form url.Values) []string {
+	switch source {
+	case SourcePath:
+		if val, ok := c.PathValue(name); ok {
+			return []string{val}
+		}
+	case SourceQuery:
+		return c.QueryArray(name)
+	case SourceHeader:
+		return c.Req.Header.Values(name)
+	case SourceForm:
+		return form[name]
+	}
+	return nil
+}
+
+// bindParams 将参数绑定到结构体字段
+func (c *Context) bindParams(val reflect.Value, form url.Values) error {
+	for _, f := range bindFields(val.Type()) {
+		field := val.FieldByIndex(f.index)
+		source, name, vals := "", "", []string(nil)
+		for _, s := range paramSources {
+			n, ok := f.names[s]
+			if !ok {
+				continue
+			}
+			source, name = s, n
+			if vals = c.paramValues(s, n, form); len(vals) > 0 {
+				break
+			}
+		}
+		if len(vals) == 0 {
+			if !f.hasDef || !field.IsZero() {
+				continue
+			}
+			vals = []string{f.def}
+		}
+		if err := setField(field, vals); err != nil {
+			return &ParamError{Source: source, Key: name, Value: strings.Join(vals, ","), Err: err}
+		}
+	}
+	return nil
+}
+
+// setField 将参数值设置到字段，支持指针和切片
+func setField(field reflect.Value, vals []string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), vals); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	if field.Kind() == reflect.Slice && !reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+		res := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+		for i, v := range vals {
+			if err := setValue(res.Index(i), v); err != nil {
+				return err
+			}
+		}
+		field.Set(res)
+		return nil
+	}
+	return setValue(field, vals[0])
+}
+
+// Typed 将 fn 转换为 HandleFunc：
+// 使用 Bind 绑定并校验请求，调用 fn，然后根据 Accept 请求头通过 Negotiate 发送响应
+// 绑定、校验、fn 及渲染返回的错误都交给引擎的错误处理函数
+// 响应状态码优先使用 fn 通过 Status 设置的状态码，其次是 Resp 实现的 StatusCoder，默认为 200；
+// Resp 为 nil 时不发送响应体，没有设置状态码时响应 204；fn 已经自行发送响应时不再渲染
+func Typed[Req, Resp any](fn func(ctx *Context, req Req) (Resp, error)) HandleFunc {
+	// 请求和响应类型用于生成 OpenAPI 文档
+	reqType := reflect.TypeOf((*Req)(nil)).Elem()
+	respType := reflect.TypeOf((*Resp)(nil)).Elem()
+	return describable(func(ctx *Context) {
+		if ctx.describe != nil {
+			ctx.describe.req, ctx.describe.resp = reqType, respType
+			return
+		}
+		var req Req
+		if err := ctx.Bind(&req); err != nil {
+			ctx.HandleError(err)
+			return
+		}
+		resp, err := fn(ctx, req)
+		if err != nil {
+			ctx.HandleError(err)
+			return
+		}
+		if ctx.written || ctx.RespData != nil {
+			return
+		}
+		if err = ctx.renderTyped(resp); err != nil {
+			ctx.HandleError(err)
+		}
+	})
+}
+
+// renderTyped 发送 Typed 处理函数的响应
+func (c *Context) renderTyped(resp any) error {
+	if isNil(resp) {
+		if c.StatusCode == 0 {
+			c.StatusCode = http.StatusNoContent
+		}
+		return nil
+	}
+	status := c.StatusCode
+	if sc, ok := resp.(StatusCoder); ok && status == 0 {
+		status = sc.StatusCode()
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return c.Negotiate(status, resp)
+}
+
+// isNil 判断值是否为 nil 或值为 nil 的指针
+func isNil(val any) bool {
+	if val == nil {
+		return true
+	}
+	rv := reflect.ValueOf(val)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
@@ -0,0 +1,317 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedPaging struct {
+	Page int `query:"page" default:"1"`
+	Size int `query:"size" default:"20"`
+}
+
+type typedReq struct {
+	typedPaging
+	ID         int64     `json:"-" path:"id"`
+	Name       string    `json:"name" form:"name"`
+	Tags       []string  `json:"tags" query:"tag"`
+	Trace      string    `json:"-" header:"X-Trace-Id"`
+	Debug      *bool     `json:"-" query:"debug"`
+	Since      time.Time `json:"-" query:"since"`
+	unexported string    `query:"unexported"`
+}
+
+func (r *typedReq) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type typedResp struct {
+	ID    int64    `json:"id" xml:"id"`
+	Name  string   `json:"name" xml:"name"`
+	Tags  []string `json:"tags" xml:"tag"`
+	Trace string   `json:"trace" xml:"trace"`
+	Page  int      `json:"page" xml:"page"`
+	Size  int      `json:"size" xml:"size"`
+	Debug bool     `json:"debug" xml:"debug"`
+	Since string   `json:"since" xml:"since"`
+}
+
+type created struct {
+	ID int64 `json:"id"`
+}
+
+func (created) StatusCode() int {
+	return http.StatusCreated
+}
+
+func echoTyped(ctx *Context, req typedReq) (typedResp, error) {
+	resp := typedResp{
+		ID: req.ID, Name: req.Name, Tags: req.Tags, Trace: req.Trace,
+		Page: req.Page, Size: req.Size, Debug: req.Debug != nil && *req.Debug,
+	}
+	if !req.Since.IsZero() {
+		resp.Since = req.Since.Format(time.DateOnly)
+	}
+	return resp, nil
+}
+
+func TestTyped(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []EngineOption
+		method   string
+		target   string
+		body     string
+		header   map[string]string
+		wantCode int
+		wantType string
+		wantBody string
+	}{
+		{
+			name:     "json body and params",
+			method:   http.MethodPost,
+			target:   "/users/12?page=3&tag=a&tag=b&debug=true&since=2024-01-02T00:00:00Z",
+			body:     `{"name":"tom","tags":["x"]}`,
+			header:   map[string]string{"Content-Type": "application/json", "X-Trace-Id": "t1"},
+			wantCode: http.StatusOK,
+			wantType: MIMEJSON,
+			wantBody: `{"id":12,"name":"tom","tags":["a","b"],"trace":"t1","page":3,"size":20,"debug":true,"since":"2024-01-02"}`,
+		},
+		{
+			name:     "json body without content type",
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `{"name":"tom","tags":["x"]}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"id":1,"name":"tom","tags":["x"],"trace":"","page":1,"size":20,"debug":false,"since":""}`,
+		},
+		{
+			name:     "form body",
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     url.Values{"name": {"jerry"}}.Encode(),
+			header:   map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			wantCode: http.StatusOK,
+			wantBody: `{"id":1,"name":"jerry","tags":null,"trace":"","page":1,"size":20,"debug":false,"since":""}`,
+		},
+		{
+			name:     "xml response",
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `{"name":"tom"}`,
+			header:   map[string]string{"Accept": "application/xml"},
+			wantCode: http.StatusOK,
+			wantType: MIMEXML,
+			wantBody: `<typedResp><id>1</id><name>tom</name><trace></trace><page>1</page><size>20</size><debug>false</debug><since></since></typedResp>`,
+		},
+		{
+			name:     "invalid json",
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `{"name":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid path param",
+			method:   http.MethodPost,
+			target:   "/users/abc",
+			body:     `{"name":"tom"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `path param "id" invalid value "abc": invalid syntax`,
+		},
+		{
+			name:     "invalid query param",
+			method:   http.MethodPost,
+			target:   "/users/1?page=x",
+			body:     `{"name":"tom"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `query param "page" invalid value "x": invalid syntax`,
+		},
+		{
+			name:     "validate method",
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `{}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "validation: name is required",
+		},
+		{
+			name: "engine validator",
+			opts: []EngineOption{WithValidator(ValidatorFunc(func(val any) error {
+				if val.(*typedReq).Size > 100 {
+					return errors.New("size too large")
+				}
+				return nil
+			}))},
+			method:   http.MethodPost,
+			target:   "/users/1?size=500",
+			body:     `{"name":"tom"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "validation: size too large",
+		},
+		{
+			name:     "unsupported media type",
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `<name>tom</name>`,
+			header:   map[string]string{"Content-Type": "text/xml"},
+			wantCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "not acceptable",
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `{"name":"tom"}`,
+			header:   map[string]string{"Accept": "image/png"},
+			wantCode: http.StatusNotAcceptable,
+		},
+		{
+			name:     "problem details",
+			opts:     []EngineOption{WithProblemDetails()},
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `{}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantType: ProblemContentType,
+			wantBody: `{"detail":"validation: name is required","instance":"/users/1","status":422,"title":"Unprocessable Entity","type":"about:blank"}`,
+		},
+		{
+			name: "error handler",
+			opts: []EngineOption{WithErrorHandler(func(ctx *Context, err error) {
+				_ = ctx.JSON(http.StatusTeapot, map[string]string{"error": err.Error()})
+			})},
+			method:   http.MethodPost,
+			target:   "/users/1",
+			body:     `{}`,
+			wantCode: http.StatusTeapot,
+			wantBody: `{"error":"validation: name is required"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEngine(tc.opts...)
+			e.POST("/users/:id", Typed(echoTyped))
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantType != "" {
+				assert.Equal(t, tc.wantType, recorder.Header().Get("Content-Type"))
+			}
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestTyped_Response(t *testing.T) {
+	e := NewEngine()
+	e.POST("/items", Typed(func(ctx *Context, req struct{}) (created, error) {
+		return created{ID: 7}, nil
+	}))
+	e.DELETE("/items/:id", Typed(func(ctx *Context, req struct {
+		ID int `path:"id"`
+	}) (*created, error) {
+		return nil, nil
+	}))
+	e.GET("/items/:id", Typed(func(ctx *Context, req struct {
+		ID int `path:"id"`
+	}) (*created, error) {
+		if req.ID == 0 {
+			return nil, NewProblem(http.StatusNotFound, "item not found")
+		}
+		if req.ID < 0 {
+			return nil, errors.New("database down")
+		}
+		ctx.Status(http.StatusAccepted)
+		return &created{ID: int64(req.ID)}, nil
+	}))
+	e.GET("/raw", Typed(func(ctx *Context, req struct{}) (any, error) {
+		return nil, ctx.String(http.StatusOK, "raw")
+	}))
+	e.GET("/list", Typed(func(ctx *Context, req struct{}) ([]int, error) {
+		return []int{1, 2}, nil
+	}))
+
+	testCases := []struct {
+		name     string
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "status coder", method: http.MethodPost, target: "/items", wantCode: http.StatusCreated, wantBody: `{"id":7}`},
+		{name: "nil response", method: http.MethodDelete, target: "/items/1", wantCode: http.StatusNoContent},
+		{name: "status coder error", method: http.MethodGet, target: "/items/0", wantCode: http.StatusNotFound, wantBody: "Not Found: item not found"},
+		{name: "internal error", method: http.MethodGet, target: "/items/-1", wantCode: http.StatusInternalServerError, wantBody: "Internal Server Error"},
+		{name: "status set by handler", method: http.MethodGet, target: "/items/3", wantCode: http.StatusAccepted, wantBody: `{"id":3}`},
+		{name: "rendered by handler", method: http.MethodGet, target: "/raw", wantCode: http.StatusOK, wantBody: "raw"},
+		{name: "slice", method: http.MethodGet, target: "/list", wantCode: http.StatusOK, wantBody: "[1,2]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.target, nil))
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestContext_Bind(t *testing.T) {
+	ctx := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	var req typedReq
+	assert.Error(t, ctx.Bind(req))
+	assert.Error(t, ctx.Bind((*typedReq)(nil)))
+
+	// 非结构体只解析请求体
+	req2 := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+	req2.Header.Set("Content-Type", "application/merge-patch+json")
+	ctx = newContext(httptest.NewRecorder(), req2)
+	var patch map[string]int
+	require.NoError(t, ctx.Bind(&patch))
+	assert.Equal(t, map[string]int{"a": 1}, patch)
+
+	// 长度未知的空请求体
+	req3 := httptest.NewRequest(http.MethodPost, "/?tag=a", strings.NewReader(""))
+	req3.ContentLength = -1
+	ctx = newContext(httptest.NewRecorder(), req3)
+	var tags struct {
+		Tags []string `query:"tag"`
+	}
+	require.NoError(t, ctx.Bind(&tags))
+	assert.Equal(t, []string{"a"}, tags.Tags)
+
+	// 不支持的字段类型
+	ctx = newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?m=1", nil))
+	var bad struct {
+		M map[string]string `query:"m"`
+	}
+	var paramErr *ParamError
+	require.ErrorAs(t, ctx.Bind(&bad), &paramErr)
+	assert.Equal(t, "m", paramErr.Key)
+
+	// 请求体中已经有值的字段不使用默认值
+	req4 := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"size":5}`))
+	ctx = newContext(httptest.NewRecorder(), req4)
+	var page struct {
+		Size int `json:"size" query:"size" default:"20"`
+	}
+	require.NoError(t, ctx.Bind(&page))
+	assert.Equal(t, 5, page.Size)
+}
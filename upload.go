@@ -0,0 +1,342 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultMultipartMemory 解析 multipart 表单时默认保存在内存中的最大字节数，超出部分写入临时文件
+const defaultMultipartMemory int64 = 32 << 20
+
+var (
+	ErrUploadTooLarge       = errors.New("upload too large")
+	ErrUploadTypeNotAllowed = errors.New("upload type not allowed")
+	ErrNotMultipart         = errors.New("request is not multipart")
+)
+
+var _ StatusCoder = &UploadError{}
+
+// UploadError 上传文件失败的错误
+type UploadError struct {
+	Filename string // 文件名
+	Err      error  // 具体原因
+}
+
+func (e *UploadError) Error() string {
+	if e.Filename == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("upload %q: %v", e.Filename, e.Err)
+}
+
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode 超出大小限制返回 413，类型不允许返回 415，其他返回 400
+func (e *UploadError) StatusCode() int {
+	switch {
+	case errors.Is(e.Err, ErrUploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(e.Err, ErrUploadTypeNotAllowed):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// uploadConfig 上传限制
+type uploadConfig struct {
+	maxMemory    int64    // 保存在内存中的最大字节数
+	maxSize      int64    // 请求体最大字节数，0 表示不限制
+	allowedExts  []string // 允许的扩展名，为空表示不限制
+	allowedTypes []string // 允许的内容类型(根据内容嗅探)，支持 image/* 形式，为空表示不限制
+}
+
+// WithMaxMultipartMemory 设置解析 multipart 表单时保存在内存中的最大字节数
+func WithMaxMultipartMemory(n int64) EngineOption {
+	return func(e *Engine) {
+		e.upload.maxMemory = n
+	}
+}
+
+// WithMaxUploadSize 设置 multipart 请求体的最大字节数，超出时返回 413
+func WithMaxUploadSize(n int64) EngineOption {
+	return func(e *Engine) {
+		e.upload.maxSize = n
+	}
+}
+
+// UploadLimitBuilder 路由级别的上传限制中间件构建器，未设置的项使用 Engine 的配置
+type UploadLimitBuilder struct {
+	MaxMemory    int64    // 保存在内存中的最大字节数
+	MaxSize      int64    // 请求体最大字节数
+	AllowedExts  []string // 允许的扩展名，例如 .png
+	AllowedTypes []string // 允许的内容类型，例如 image/png、image/*
+}
+
+// Build 构建上传限制中间件
+func (u UploadLimitBuilder) Build() HandleFunc {
+	return func(ctx *Context) {
+		cfg := ctx.uploadConfig()
+		if u.MaxMemory > 0 {
+			cfg.maxMemory = u.MaxMemory
+		}
+		if u.MaxSize > 0 {
+			cfg.maxSize = u.MaxSize
+		}
+		if len(u.AllowedExts) > 0 {
+			cfg.allowedExts = u.AllowedExts
+		}
+		if len(u.AllowedTypes) > 0 {
+			cfg.allowedTypes = u.AllowedTypes
+		}
+		ctx.upload = &cfg
+
+		// Content-Length 已经超出限制时直接拒绝，不再读取请求体
+		if cfg.maxSize > 0 && ctx.Req.ContentLength > cfg.maxSize {
+			ctx.AbortWithError(&UploadError{Err: ErrUploadTooLarge})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// uploadConfig 返回当前请求生效的上传限制
+func (c *Context) uploadConfig() uploadConfig {
+	if c.upload != nil {
+		return *c.upload
+	}
+	if c.engine != nil {
+		return c.engine.upload
+	}
+	return uploadConfig{maxMemory: defaultMultipartMemory}
+}
+
+// limitBody 按上传限制包装请求体
+func (c *Context) limitBody(cfg uploadConfig) {
+	if cfg.maxSize > 0 && !c.bodyLimited {
+		c.Req.Body = http.MaxBytesReader(c.Resp, c.Req.Body, cfg.maxSize)
+		c.bodyLimited = true
+	}
+}
+
+// uploadErr 将读取请求体的错误转换为 UploadError
+func uploadErr(filename string, err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &UploadError{Filename: filename, Err: ErrUploadTooLarge}
+	}
+	if errors.Is(err, http.ErrNotMultipart) {
+		return &UploadError{Filename: filename, Err: ErrNotMultipart}
+	}
+	return &UploadError{Filename: filename, Err: err}
+}
+
+// MultipartForm 获取Multipart表单
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if c.Req.MultipartForm != nil {
+		return c.Req.MultipartForm, nil
+	}
+	cfg := c.uploadConfig()
+	c.limitBody(cfg)
+	err := c.Req.ParseMultipartForm(cfg.maxMemory)
+	if err != nil {
+		return nil, uploadErr("", err)
+	}
+	return c.Req.MultipartForm, nil
+}
+
+// FormFile 获取上传的文件，并按照扩展名和内容类型的允许列表进行校验
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	files := form.File[name]
+	if len(files) == 0 {
+		return nil, &UploadError{Err: http.ErrMissingFile}
+	}
+	fh := files[0]
+	if err = c.checkFileHeader(fh); err != nil {
+		return nil, err
+	}
+	return fh, nil
+}
+
+// checkFileHeader 校验已解析的上传文件
+func (c *Context) checkFileHeader(fh *multipart.FileHeader) error {
+	cfg := c.uploadConfig()
+	if !allowedExt(cfg.allowedExts, fh.Filename) {
+		return &UploadError{Filename: fh.Filename, Err: ErrUploadTypeNotAllowed}
+	}
+	if len(cfg.allowedTypes) == 0 {
+		return nil
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return &UploadError{Filename: fh.Filename, Err: err}
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return &UploadError{Filename: fh.Filename, Err: err}
+	}
+	if !allowedType(cfg.allowedTypes, http.DetectContentType(head[:n])) {
+		return &UploadError{Filename: fh.Filename, Err: ErrUploadTypeNotAllowed}
+	}
+	return nil
+}
+
+// SaveUploadedFile 将上传的文件保存到 dst，必要时创建目录
+func (c *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = saveFile(src, dst)
+	return err
+}
+
+// saveFile 将 src 写入 dst，必要时创建目录
+// 先写入同一目录下的临时文件，成功后再重命名，失败时不会留下不完整的文件或覆盖已有的文件
+func saveFile(src io.Reader, dst string) (int64, error) {
+	dir := filepath.Dir(dst)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return 0, err
+	}
+	out, err := os.CreateTemp(dir, "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, src)
+	if err == nil {
+		// CreateTemp 创建的文件只有所有者可以读写，与 os.Create 创建的文件保持一致
+		err = out.Chmod(0o644)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), dst)
+	}
+	if err != nil {
+		_ = os.Remove(out.Name())
+	}
+	return n, err
+}
+
+// UploadStream 流式读取 multipart 请求，适用于不能整体缓存的大文件上传
+type UploadStream struct {
+	ctx    *Context
+	reader *multipart.Reader
+	cfg    uploadConfig
+}
+
+// UploadStream 返回流式读取 multipart 请求的 UploadStream
+func (c *Context) UploadStream() (*UploadStream, error) {
+	cfg := c.uploadConfig()
+	c.limitBody(cfg)
+	reader, err := c.Req.MultipartReader()
+	if err != nil {
+		return nil, uploadErr("", err)
+	}
+	return &UploadStream{ctx: c, reader: reader, cfg: cfg}, nil
+}
+
+// Next 返回下一个部分，没有更多部分时返回 io.EOF
+// 文件部分会校验扩展名，并嗅探内容类型后按允许列表校验
+func (s *UploadStream) Next() (*UploadPart, error) {
+	part, err := s.reader.NextPart()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, uploadErr("", err)
+	}
+	res := &UploadPart{Part: part, reader: bufio.NewReaderSize(part, 512)}
+	filename := part.FileName()
+	if filename == "" {
+		return res, nil
+	}
+	if !allowedExt(s.cfg.allowedExts, filename) {
+		return nil, &UploadError{Filename: filename, Err: ErrUploadTypeNotAllowed}
+	}
+	head, err := res.reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, uploadErr(filename, err)
+	}
+	res.ContentType = http.DetectContentType(head)
+	if len(s.cfg.allowedTypes) > 0 && !allowedType(s.cfg.allowedTypes, res.ContentType) {
+		return nil, &UploadError{Filename: filename, Err: ErrUploadTypeNotAllowed}
+	}
+	return res, nil
+}
+
+// UploadPart multipart 请求中的一个部分
+type UploadPart struct {
+	*multipart.Part
+	ContentType string // 嗅探得到的内容类型，仅文件部分有值
+	reader      *bufio.Reader
+}
+
+// Read 读取该部分的内容
+func (p *UploadPart) Read(b []byte) (int, error) {
+	return p.reader.Read(b)
+}
+
+// SaveTo 将该部分的内容流式写入 dst，返回写入的字节数，失败时 dst 保持不变
+func (p *UploadPart) SaveTo(dst string) (int64, error) {
+	n, err := saveFile(p, dst)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return n, &UploadError{Filename: p.FileName(), Err: ErrUploadTooLarge}
+		}
+	}
+	return n, err
+}
+
+// allowedExt 判断文件扩展名是否在允许列表中
+func allowedExt(exts []string, filename string) bool {
+	if len(exts) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range exts {
+		if strings.ToLower(allowed) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedType 判断内容类型是否在允许列表中，支持 image/* 形式
+func allowedType(types []string, contentType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range types {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader PNG 文件头，用于内容嗅探
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// newUploadRequest 构造包含一个普通字段和一个文件的 multipart 请求
+func newUploadRequest(t *testing.T, path string, filename string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("name", "go-web"))
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestContext_FormFile(t *testing.T) {
+	dir := t.TempDir()
+	e := NewEngine(WithMaxUploadSize(1 << 20))
+	handler := func(ctx *Context) {
+		fh, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		if err = ctx.SaveUploadedFile(fh, filepath.Join(dir, "uploads", fh.Filename)); err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		name, _ := ctx.FormValue("name")
+		_ = ctx.String(http.StatusOK, name+":"+fh.Filename)
+	}
+	e.POST("/upload", handler)
+	e.POST("/avatar", UploadLimitBuilder{
+		MaxSize:      1024,
+		AllowedExts:  []string{".png"},
+		AllowedTypes: []string{"image/*"},
+	}.Build(), handler)
+
+	pngContent := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 100)...)
+	testCases := []struct {
+		name     string
+		req      *http.Request
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "ok",
+			req:      newUploadRequest(t, "/upload", "hello.txt", []byte("hello")),
+			wantCode: http.StatusOK,
+			wantBody: "go-web:hello.txt",
+		},
+		{
+			name:     "engine limit",
+			req:      newUploadRequest(t, "/upload", "big.txt", bytes.Repeat([]byte("a"), 2<<20)),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "route allowed",
+			req:      newUploadRequest(t, "/avatar", "avatar.png", pngContent),
+			wantCode: http.StatusOK,
+			wantBody: "go-web:avatar.png",
+		},
+		{
+			name:     "route limit",
+			req:      newUploadRequest(t, "/avatar", "avatar.png", append(pngContent, bytes.Repeat([]byte{0}, 2048)...)),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "extension not allowed",
+			req:      newUploadRequest(t, "/avatar", "avatar.exe", pngContent),
+			wantCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "content type not allowed",
+			req:      newUploadRequest(t, "/avatar", "avatar.png", []byte("<html><script>alert(1)</script></html>")),
+			wantCode: http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, tc.req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+			}
+		})
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "uploads", "hello.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestContext_UploadStream(t *testing.T) {
+	dir := t.TempDir()
+	e := NewEngine()
+	e.POST("/stream", UploadLimitBuilder{MaxSize: 64 << 10, AllowedTypes: []string{"image/png"}}.Build(), func(ctx *Context) {
+		stream, err := ctx.UploadStream()
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		var res []string
+		for {
+			part, err := stream.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				ctx.AbortWithError(err)
+				return
+			}
+			if part.FileName() == "" {
+				val, _ := io.ReadAll(part)
+				res = append(res, part.FormName()+"="+string(val))
+				continue
+			}
+			n, err := part.SaveTo(filepath.Join(dir, part.FileName()))
+			if err != nil {
+				ctx.AbortWithError(err)
+				return
+			}
+			res = append(res, part.FileName()+":"+part.ContentType+":"+strconv.FormatInt(n, 10))
+		}
+		_ = ctx.String(http.StatusOK, strings.Join(res, ","))
+	})
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{1}, 20000-len(pngHeader))...)
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, newUploadRequest(t, "/stream", "big.png", content))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "name=go-web,big.png:image/png:20000", recorder.Body.String())
+	saved, err := os.ReadFile(filepath.Join(dir, "big.png"))
+	require.NoError(t, err)
+	assert.Equal(t, content, saved)
+
+	// 没有 Content-Length 的超大请求在读取时被拒绝
+	req := newUploadRequest(t, "/stream", "huge.png", append(append([]byte{}, pngHeader...), make([]byte, 128<<10)...))
+	req.ContentLength = -1
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	_, err = os.Stat(filepath.Join(dir, "huge.png"))
+	assert.True(t, os.IsNotExist(err))
+
+	// 超出限制时不会覆盖已有的文件，也不会留下临时文件
+	req = newUploadRequest(t, "/stream", "big.png", append(append([]byte{}, pngHeader...), make([]byte, 128<<10)...))
+	req.ContentLength = -1
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	saved, err = os.ReadFile(filepath.Join(dir, "big.png"))
+	require.NoError(t, err)
+	assert.Equal(t, content, saved)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "big.png", entries[0].Name())
+
+	// 非 multipart 请求
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/stream", strings.NewReader("plain")))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,728 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// websocketGUID RFC 6455 中用于计算 Sec-WebSocket-Accept 的固定 GUID
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MessageType websocket 消息类型
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1  // 文本消息
+	BinaryMessage MessageType = 2  // 二进制消息
+	CloseMessage  MessageType = 8  // 关闭帧
+	PingMessage   MessageType = 9  // ping 帧
+	PongMessage   MessageType = 10 // pong 帧
+)
+
+// continuationFrame 分片消息的后续帧
+const continuationFrame = 0
+
+// 关闭状态码
+const (
+	CloseNormalClosure      = 1000
+	CloseGoingAway          = 1001
+	CloseProtocolError      = 1002
+	CloseUnsupportedData    = 1003
+	CloseNoStatusReceived   = 1005
+	CloseInvalidPayloadData = 1007
+	CloseMessageTooBig      = 1009
+	CloseInternalServerErr  = 1011
+)
+
+// defaultReadLimit 默认单条消息的最大字节数
+const defaultReadLimit int64 = 32 << 20
+
+var (
+	ErrBadHandshake    = errors.New("websocket: bad handshake")
+	ErrOriginForbidden = errors.New("websocket: origin not allowed")
+	ErrReadLimit       = errors.New("websocket: read limit exceeded")
+	ErrConnClosed      = errors.New("websocket: connection closed")
+)
+
+// CloseError 对端发送关闭帧时返回的错误
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+// WebSocketOption websocket 升级的可选项
+type WebSocketOption func(cfg *webSocketConfig)
+
+// webSocketConfig websocket 升级配置
+type webSocketConfig struct {
+	subprotocols []string                     // 服务端支持的子协议，按优先级排序
+	checkOrigin  func(req *http.Request) bool // Origin 校验函数
+	compression  bool                         // 是否协商 permessage-deflate
+	readLimit    int64                        // 单条消息最大字节数
+}
+
+// WithSubprotocols 设置服务端支持的子协议
+func WithSubprotocols(protocols ...string) WebSocketOption {
+	return func(cfg *webSocketConfig) {
+		cfg.subprotocols = protocols
+	}
+}
+
+// WithOriginChecker 设置 Origin 校验函数，默认只允许同源请求
+func WithOriginChecker(check func(req *http.Request) bool) WebSocketOption {
+	return func(cfg *webSocketConfig) {
+		cfg.checkOrigin = check
+	}
+}
+
+// WithCompression 开启 permessage-deflate 压缩协商
+func WithCompression() WebSocketOption {
+	return func(cfg *webSocketConfig) {
+		cfg.compression = true
+	}
+}
+
+// WithReadLimit 设置单条消息的最大字节数
+func WithReadLimit(limit int64) WebSocketOption {
+	return func(cfg *webSocketConfig) {
+		cfg.readLimit = limit
+	}
+}
+
+// sameOrigin 默认的 Origin 校验，没有 Origin 或 Origin 与 Host 一致时通过
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	idx := strings.Index(origin, "://")
+	if idx < 0 {
+		return false
+	}
+	return strings.EqualFold(origin[idx+3:], req.Host)
+}
+
+// Upgrade 将当前请求升级为 websocket 连接
+// 握手失败时会写入对应的错误响应并返回错误，成功后 Context 的响应不再由 Engine 发送
+func (c *Context) Upgrade(opts ...WebSocketOption) (*WebSocketConn, error) {
+	cfg := &webSocketConfig{
+		checkOrigin: sameOrigin,
+		readLimit:   defaultReadLimit,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	req := c.Req
+	if req.Method != http.MethodGet {
+		return nil, c.handshakeError(http.StatusMethodNotAllowed, "websocket: method not GET")
+	}
+	if !headerContainsToken(req.Header, "Connection", "upgrade") ||
+		!headerContainsToken(req.Header, "Upgrade", "websocket") {
+		return nil, c.handshakeError(http.StatusBadRequest, "websocket: missing upgrade headers")
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.Resp.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, c.handshakeError(http.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, c.handshakeError(http.StatusBadRequest, "websocket: invalid Sec-WebSocket-Key")
+	}
+	if !cfg.checkOrigin(req) {
+		c.StatusCode = http.StatusForbidden
+		c.RespData = []byte(ErrOriginForbidden.Error())
+		return nil, ErrOriginForbidden
+	}
+
+	subprotocol := selectSubprotocol(req, cfg.subprotocols)
+	compress := cfg.compression && offersDeflate(req)
+
+	netConn, brw, err := http.NewResponseController(c.Resp).Hijack()
+	if err != nil {
+		return nil, c.handshakeError(http.StatusInternalServerError, "websocket: "+err.Error())
+	}
+	c.written = true
+
+	var buf bytes.Buffer
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	buf.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	buf.WriteString("Sec-WebSocket-Accept: " + computeAcceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		buf.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		buf.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	// 中间件设置的响应头(例如会话 Cookie)同样需要带上
+	for k, vals := range c.Resp.Header() {
+		for _, v := range vals {
+			buf.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	buf.WriteString("\r\n")
+
+	_ = netConn.SetDeadline(time.Time{})
+	if _, err = netConn.Write(buf.Bytes()); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	ws := newWebSocketConn(netConn, brw.Reader, true)
+	ws.subprotocol = subprotocol
+	ws.compress = compress
+	ws.readLimit = cfg.readLimit
+	return ws, nil
+}
+
+// handshakeError 记录握手失败的响应
+func (c *Context) handshakeError(status int, msg string) error {
+	c.StatusCode = status
+	c.RespData = []byte(msg)
+	return fmt.Errorf("%w: %s", ErrBadHandshake, msg)
+}
+
+// IsWebSocket 判断请求是否为 websocket 升级请求
+func (c *Context) IsWebSocket() bool {
+	return headerContainsToken(c.Req.Header, "Connection", "upgrade") &&
+		headerContainsToken(c.Req.Header, "Upgrade", "websocket")
+}
+
+// computeAcceptKey 计算 Sec-WebSocket-Accept
+func computeAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContainsToken 判断以逗号分隔的请求头中是否包含指定 token(忽略大小写)
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, val := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(val, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectSubprotocol 按服务端优先级选出客户端也支持的子协议
+func selectSubprotocol(req *http.Request, supported []string) string {
+	var offered []string
+	for _, val := range req.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(val, ",") {
+			offered = append(offered, strings.TrimSpace(p))
+		}
+	}
+	for _, s := range supported {
+		for _, o := range offered {
+			if s == o {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// offersDeflate 判断客户端是否提供 permessage-deflate 扩展
+func offersDeflate(req *http.Request) bool {
+	for _, val := range req.Header.Values("Sec-WebSocket-Extensions") {
+		for _, ext := range strings.Split(val, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(ext), ";")
+			if strings.TrimSpace(name) == "permessage-deflate" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WebSocketConn websocket 连接
+// 读操作只能在一个 goroutine 中进行，写操作是并发安全的
+type WebSocketConn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	isServer    bool   // 服务端不掩码发送，要求客户端帧必须掩码
+	subprotocol string // 协商后的子协议
+	compress    bool   // 是否启用 permessage-deflate
+	readLimit   int64  // 单条消息最大字节数
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closeSent bool
+
+	PingHandler func(data []byte) error // 收到 ping 时的回调，默认回复 pong
+	PongHandler func(data []byte) error // 收到 pong 时的回调
+}
+
+// newWebSocketConn 创建 websocket 连接
+func newWebSocketConn(conn net.Conn, br *bufio.Reader, isServer bool) *WebSocketConn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return &WebSocketConn{
+		conn:      conn,
+		br:        br,
+		isServer:  isServer,
+		readLimit: defaultReadLimit,
+	}
+}
+
+// Subprotocol 返回协商后的子协议
+func (w *WebSocketConn) Subprotocol() string {
+	return w.subprotocol
+}
+
+// RemoteAddr 返回对端地址
+func (w *WebSocketConn) RemoteAddr() net.Addr {
+	return w.conn.RemoteAddr()
+}
+
+// SetReadDeadline 设置读超时
+func (w *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return w.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline 设置写超时
+func (w *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return w.conn.SetWriteDeadline(t)
+}
+
+// frameHeader 帧头信息
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	opcode int
+	length int64
+	masked bool
+	mask   [4]byte
+}
+
+// readFrameHeader 读取一个帧头
+func (w *WebSocketConn) readFrameHeader() (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(w.br, b[:2]); err != nil {
+		return h, err
+	}
+	h.fin = b[0]&0x80 != 0
+	h.rsv1 = b[0]&0x40 != 0
+	if b[0]&0x30 != 0 {
+		return h, w.protocolError("reserved bits set")
+	}
+	h.opcode = int(b[0] & 0x0f)
+	h.masked = b[1]&0x80 != 0
+	length := int64(b[1] & 0x7f)
+	switch length {
+	case 126:
+		if _, err := io.ReadFull(w.br, b[:2]); err != nil {
+			return h, err
+		}
+		length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(w.br, b[:8]); err != nil {
+			return h, err
+		}
+		length = int64(binary.BigEndian.Uint64(b[:8]))
+		if length < 0 {
+			return h, w.protocolError("invalid payload length")
+		}
+	}
+	h.length = length
+	if h.masked {
+		if _, err := io.ReadFull(w.br, h.mask[:]); err != nil {
+			return h, err
+		}
+	}
+	if w.isServer && !h.masked {
+		return h, w.protocolError("client frame not masked")
+	}
+	if !w.isServer && h.masked {
+		return h, w.protocolError("server frame masked")
+	}
+	if h.opcode >= 8 {
+		if !h.fin || h.length > 125 {
+			return h, w.protocolError("invalid control frame")
+		}
+		if h.rsv1 {
+			return h, w.protocolError("compressed control frame")
+		}
+	}
+	if h.rsv1 && !w.compress {
+		return h, w.protocolError("unexpected RSV1")
+	}
+	return h, nil
+}
+
+// readPayload 读取帧数据并去除掩码
+func (w *WebSocketConn) readPayload(h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+	if _, err := io.ReadFull(w.br, payload); err != nil {
+		return nil, err
+	}
+	if h.masked {
+		maskBytes(h.mask, payload)
+	}
+	return payload, nil
+}
+
+// protocolError 发送 1002 关闭帧并返回协议错误
+func (w *WebSocketConn) protocolError(msg string) error {
+	_ = w.writeClose(CloseProtocolError, msg)
+	return errors.New("websocket: protocol error: " + msg)
+}
+
+// ReadMessage 读取一条完整的消息
+// 分片消息会被自动组装，控制帧在内部处理：ping 自动回复 pong，收到关闭帧时回复关闭帧并返回 *CloseError
+func (w *WebSocketConn) ReadMessage() (MessageType, []byte, error) {
+	var (
+		msgType    MessageType
+		compressed bool
+		buf        []byte
+		started    bool
+	)
+	for {
+		h, err := w.readFrameHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+		if h.opcode < 8 && int64(len(buf))+h.length > w.readLimit {
+			_ = w.writeClose(CloseMessageTooBig, "")
+			return 0, nil, ErrReadLimit
+		}
+		payload, err := w.readPayload(h)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch h.opcode {
+		case int(PingMessage):
+			if err = w.handlePing(payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case int(PongMessage):
+			if w.PongHandler != nil {
+				if err = w.PongHandler(payload); err != nil {
+					return 0, nil, err
+				}
+			}
+			continue
+		case int(CloseMessage):
+			return 0, nil, w.handleClose(payload)
+		case int(TextMessage), int(BinaryMessage):
+			if started {
+				return 0, nil, w.protocolError("expected continuation frame")
+			}
+			started = true
+			msgType = MessageType(h.opcode)
+			compressed = h.rsv1
+		case continuationFrame:
+			if !started {
+				return 0, nil, w.protocolError("unexpected continuation frame")
+			}
+			if h.rsv1 {
+				return 0, nil, w.protocolError("RSV1 on continuation frame")
+			}
+		default:
+			return 0, nil, w.protocolError("unknown opcode")
+		}
+
+		buf = append(buf, payload...)
+		if !h.fin {
+			continue
+		}
+
+		if compressed {
+			buf, err = w.decompress(buf)
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+		if msgType == TextMessage && !utf8.Valid(buf) {
+			_ = w.writeClose(CloseInvalidPayloadData, "invalid utf8")
+			return 0, nil, errors.New("websocket: invalid utf8 in text message")
+		}
+		return msgType, buf, nil
+	}
+}
+
+// ReadJSON 读取一条消息并解析为 JSON
+func (w *WebSocketConn) ReadJSON(val any) error {
+	_, data, err := w.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, val)
+}
+
+// handlePing 处理 ping 帧
+func (w *WebSocketConn) handlePing(payload []byte) error {
+	if w.PingHandler != nil {
+		return w.PingHandler(payload)
+	}
+	err := w.writeFrame(int(PongMessage), true, false, payload)
+	if errors.Is(err, ErrConnClosed) {
+		return nil
+	}
+	return err
+}
+
+// validCloseCode 判断对端发送的关闭状态码是否合法，见 RFC 6455 第 7.4 节
+// 1005、1006 和 1015 只用于表示状态，不能出现在关闭帧中；3000 到 4999 由应用和库使用
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// handleClose 处理关闭帧，回复关闭帧后关闭底层连接
+func (w *WebSocketConn) handleClose(payload []byte) error {
+	code, reason := CloseNoStatusReceived, ""
+	if len(payload) == 1 {
+		_ = w.protocolError("invalid close payload")
+		_ = w.conn.Close()
+		return &CloseError{Code: CloseProtocolError}
+	}
+	if len(payload) >= 2 {
+		code = int(binary.BigEndian.Uint16(payload[:2]))
+		reason = string(payload[2:])
+		if !validCloseCode(code) || !utf8.ValidString(reason) {
+			_ = w.writeClose(CloseProtocolError, "")
+			_ = w.conn.Close()
+			return &CloseError{Code: CloseProtocolError}
+		}
+	}
+	replyCode := code
+	if code == CloseNoStatusReceived {
+		replyCode = CloseNormalClosure
+	}
+	_ = w.writeClose(replyCode, "")
+	_ = w.conn.Close()
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// decompress 解压 permessage-deflate 消息
+func (w *WebSocketConn) decompress(data []byte) ([]byte, error) {
+	// RFC 7692: 补齐被省略的 0x00 0x00 0xff 0xff，并追加一个空的最终块使读取能正常结束
+	src := io.MultiReader(bytes.NewReader(data),
+		strings.NewReader("\x00\x00\xff\xff\x01\x00\x00\xff\xff"))
+	fr := flate.NewReader(src)
+	defer fr.Close()
+	res, err := io.ReadAll(io.LimitReader(fr, w.readLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(res)) > w.readLimit {
+		_ = w.writeClose(CloseMessageTooBig, "")
+		return nil, ErrReadLimit
+	}
+	return res, nil
+}
+
+// compressData 使用 permessage-deflate 压缩消息
+func compressData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err = fw.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0x00, 0x00, 0xff, 0xff}), nil
+}
+
+// WriteMessage 发送一条完整的消息
+func (w *WebSocketConn) WriteMessage(mt MessageType, data []byte) error {
+	switch mt {
+	case TextMessage, BinaryMessage:
+		if w.compress {
+			compressed, err := compressData(data)
+			if err != nil {
+				return err
+			}
+			return w.writeFrame(int(mt), true, true, compressed)
+		}
+		return w.writeFrame(int(mt), true, false, data)
+	case PingMessage, PongMessage:
+		if len(data) > 125 {
+			return errors.New("websocket: control frame payload too large")
+		}
+		return w.writeFrame(int(mt), true, false, data)
+	case CloseMessage:
+		return w.writeFrame(int(mt), true, false, data)
+	default:
+		return errors.New("websocket: unknown message type")
+	}
+}
+
+// WriteJSON 以文本消息发送 JSON
+func (w *WebSocketConn) WriteJSON(val any) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return w.WriteMessage(TextMessage, data)
+}
+
+// Ping 发送 ping 帧
+func (w *WebSocketConn) Ping(data []byte) error {
+	return w.WriteMessage(PingMessage, data)
+}
+
+// NextWriter 返回一个分片写入器，每次 Write 都会发送一个分片，Close 时发送结束帧
+// 分片写入不使用压缩
+func (w *WebSocketConn) NextWriter(mt MessageType) (io.WriteCloser, error) {
+	if mt != TextMessage && mt != BinaryMessage {
+		return nil, errors.New("websocket: fragmented message must be text or binary")
+	}
+	return &fragmentWriter{conn: w, opcode: int(mt)}, nil
+}
+
+// fragmentWriter 分片消息写入器
+type fragmentWriter struct {
+	conn    *WebSocketConn
+	opcode  int
+	started bool
+	closed  bool
+}
+
+func (f *fragmentWriter) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, ErrConnClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := f.conn.writeFrame(f.nextOpcode(), false, false, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (f *fragmentWriter) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.conn.writeFrame(f.nextOpcode(), true, false, nil)
+}
+
+// nextOpcode 第一个分片使用消息类型，后续分片使用 continuation
+func (f *fragmentWriter) nextOpcode() int {
+	if f.started {
+		return continuationFrame
+	}
+	f.started = true
+	return f.opcode
+}
+
+// writeFrame 写入一个帧，客户端模式下会对数据进行掩码
+func (w *WebSocketConn) writeFrame(opcode int, fin bool, rsv1 bool, payload []byte) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	if w.closeSent {
+		return ErrConnClosed
+	}
+
+	var header [14]byte
+	if fin {
+		header[0] |= 0x80
+	}
+	if rsv1 {
+		header[0] |= 0x40
+	}
+	header[0] |= byte(opcode)
+	n := 2
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+		n += 2
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+		n += 8
+	}
+
+	data := payload
+	if !w.isServer {
+		header[1] |= 0x80
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		copy(header[n:], mask[:])
+		n += 4
+		data = make([]byte, length)
+		copy(data, payload)
+		maskBytes(mask, data)
+	}
+
+	if opcode == int(CloseMessage) {
+		w.closeSent = true
+	}
+	_, err := w.conn.Write(append(header[:n:n], data...))
+	return err
+}
+
+// writeClose 发送关闭帧
+func (w *WebSocketConn) writeClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	return w.writeFrame(int(CloseMessage), true, false, payload)
+}
+
+// Close 发送关闭帧并关闭底层连接
+func (w *WebSocketConn) Close() error {
+	return w.CloseWithReason(CloseNormalClosure, "")
+}
+
+// CloseWithReason 发送指定状态码的关闭帧并关闭底层连接
+func (w *WebSocketConn) CloseWithReason(code int, reason string) error {
+	var err error
+	w.closeOnce.Do(func() {
+		_ = w.writeClose(code, reason)
+		err = w.conn.Close()
+	})
+	return err
+}
+
+// maskBytes 对数据进行掩码/去掩码
+func maskBytes(mask [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= mask[i%4]
+	}
+}
@@ -0,0 +1,279 @@
+package web
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialWebSocket 建立测试用的 websocket 客户端连接
+func dialWebSocket(t *testing.T, server *httptest.Server, path string, header http.Header) (*WebSocketConn, *http.Response) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	require.NoError(t, err)
+	for k, vals := range header {
+		req.Header[k] = vals
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")))
+	require.NoError(t, req.Write(conn))
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	require.NoError(t, err)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		return nil, resp
+	}
+	return newWebSocketConn(conn, br, false), resp
+}
+
+func TestContext_Upgrade(t *testing.T) {
+	e := NewEngine()
+	e.GET("/echo", func(ctx *Context) {
+		ws, err := ctx.Upgrade(WithSubprotocols("chat"), WithCompression())
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			mt, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err = ws.WriteMessage(mt, data); err != nil {
+				return
+			}
+		}
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Sec-WebSocket-Protocol", "superchat, chat")
+	header.Set("Sec-WebSocket-Extensions", "permessage-deflate; client_max_window_bits")
+	client, resp := dialWebSocket(t, server, "/echo", header)
+	require.NotNil(t, client)
+	assert.Equal(t, "chat", resp.Header.Get("Sec-WebSocket-Protocol"))
+	assert.Equal(t, computeAcceptKey(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))),
+		resp.Header.Get("Sec-WebSocket-Accept"))
+	assert.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	client.compress = true
+
+	// 普通消息
+	require.NoError(t, client.WriteMessage(TextMessage, []byte("hello")))
+	mt, data, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, TextMessage, mt)
+	assert.Equal(t, "hello", string(data))
+
+	// 较大的压缩消息
+	big := []byte(strings.Repeat("go-web ", 20000))
+	require.NoError(t, client.WriteMessage(BinaryMessage, big))
+	mt, data, err = client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, BinaryMessage, mt)
+	assert.Equal(t, big, data)
+
+	// 分片消息，中间插入 ping
+	pong := make(chan string, 1)
+	client.PongHandler = func(data []byte) error {
+		pong <- string(data)
+		return nil
+	}
+	w, err := client.NextWriter(TextMessage)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("frag"))
+	require.NoError(t, err)
+	require.NoError(t, client.Ping([]byte("are you there")))
+	_, err = w.Write([]byte("mented"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, data, err = client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "fragmented", string(data))
+	assert.Equal(t, "are you there", <-pong)
+
+	// 关闭握手
+	require.NoError(t, client.writeClose(CloseGoingAway, "bye"))
+	_, _, err = client.ReadMessage()
+	var closeErr *CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, CloseGoingAway, closeErr.Code)
+}
+
+func TestWebSocketConn_CloseCode(t *testing.T) {
+	serverErr := make(chan error, 1)
+	e := NewEngine()
+	e.GET("/ws", func(ctx *Context) {
+		ws, err := ctx.Upgrade()
+		if err != nil {
+			return
+		}
+		_, _, err = ws.ReadMessage()
+		serverErr <- err
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	testCases := []struct {
+		code      int
+		wantReply int
+	}{
+		{code: CloseNormalClosure, wantReply: CloseNormalClosure},
+		{code: CloseInternalServerErr, wantReply: CloseInternalServerErr},
+		{code: 3000, wantReply: 3000},
+		{code: 4999, wantReply: 4999},
+		{code: 999, wantReply: CloseProtocolError},
+		{code: 1004, wantReply: CloseProtocolError},
+		{code: CloseNoStatusReceived, wantReply: CloseProtocolError},
+		{code: 1006, wantReply: CloseProtocolError},
+		{code: 1015, wantReply: CloseProtocolError},
+		{code: 2000, wantReply: CloseProtocolError},
+		{code: 5000, wantReply: CloseProtocolError},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.code), func(t *testing.T) {
+			client, _ := dialWebSocket(t, server, "/ws", nil)
+			require.NotNil(t, client)
+			require.NoError(t, client.writeClose(tc.code, ""))
+			_, _, err := client.ReadMessage()
+			var closeErr *CloseError
+			require.ErrorAs(t, err, &closeErr)
+			assert.Equal(t, tc.wantReply, closeErr.Code)
+			// 服务端收到非法状态码时按协议错误处理
+			require.ErrorAs(t, <-serverErr, &closeErr)
+			assert.Equal(t, tc.wantReply, closeErr.Code)
+		})
+	}
+}
+
+func TestContext_UpgradeRejected(t *testing.T) {
+	e := NewEngine()
+	e.GET("/ws", func(ctx *Context) {
+		_, _ = ctx.Upgrade()
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	// 普通请求
+	resp, err := http.Get(server.URL + "/ws")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// 跨域请求
+	header := http.Header{}
+	header.Set("Origin", "http://evil.example.com")
+	client, resp := dialWebSocket(t, server, "/ws", header)
+	assert.Nil(t, client)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestContext_UpgradeWithGroupMiddleware(t *testing.T) {
+	e := NewEngine()
+	ws := e.Group("/ws")
+	ws.Use(func(ctx *Context) {
+		if ctx.Req.Header.Get("Authorization") != "token" {
+			ctx.Status(http.StatusUnauthorized)
+			ctx.Abort()
+			return
+		}
+		ctx.SetCookie(&http.Cookie{Name: "session_id", Value: "abc"})
+		ctx.Next()
+	})
+	ws.GET("/chat", func(ctx *Context) {
+		conn, err := ctx.Upgrade()
+		if err != nil {
+			return
+		}
+		_ = conn.WriteMessage(TextMessage, []byte("welcome"))
+		_ = conn.Close()
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	client, resp := dialWebSocket(t, server, "/ws/chat", nil)
+	assert.Nil(t, client)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	header := http.Header{}
+	header.Set("Authorization", "token")
+	client, resp = dialWebSocket(t, server, "/ws/chat", header)
+	require.NotNil(t, client)
+	assert.Contains(t, resp.Header.Get("Set-Cookie"), "session_id=abc")
+	_, data, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "welcome", string(data))
+}
+
+func TestHub_Broadcast(t *testing.T) {
+	hub := NewHub()
+	joined := make(chan struct{}, 3)
+	e := NewEngine()
+	e.GET("/room/:name", func(ctx *Context) {
+		conn, err := ctx.Upgrade()
+		if err != nil {
+			return
+		}
+		room := ctx.Param("name")
+		hub.Join(room, conn)
+		defer hub.LeaveAll(conn)
+		joined <- struct{}{}
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = hub.Broadcast(room, TextMessage, data)
+		}
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	var clients []*WebSocketConn
+	for i := 0; i < 3; i++ {
+		room := "a"
+		if i == 2 {
+			room = "b"
+		}
+		client, _ := dialWebSocket(t, server, "/room/"+room, nil)
+		require.NotNil(t, client)
+		clients = append(clients, client)
+		<-joined
+	}
+	assert.Equal(t, 2, hub.Count("a"))
+	assert.Equal(t, 1, hub.Count("b"))
+
+	require.NoError(t, clients[0].WriteMessage(TextMessage, []byte("hi room a")))
+	for i := 0; i < 2; i++ {
+		_, data, err := clients[i].ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "hi room a", string(data))
+	}
+
+	// b 房间的连接收不到 a 房间的消息
+	require.NoError(t, clients[2].SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, _, err := clients[2].ReadMessage()
+	assert.Error(t, err)
+
+	for _, client := range clients {
+		_ = client.Close()
+	}
+	assert.Eventually(t, func() bool {
+		return len(hub.Rooms()) == 0
+	}, time.Second, 10*time.Millisecond, fmt.Sprint(hub.Rooms()))
+}
@@ -0,0 +1,218 @@
+// Package webtest 提供在进程内测试 web.Engine 的工具，无需监听端口
+package webtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// defaultBaseURL 请求默认使用的地址
+const defaultBaseURL = "http://example.com"
+
+// Client 测试客户端，直接调用 handler 处理请求，并像浏览器一样在多次请求之间保存 Cookie
+type Client struct {
+	t       testing.TB
+	handler http.Handler
+	base    *url.URL
+	jar     http.CookieJar
+	header  http.Header // 每个请求都会带上的请求头
+}
+
+// New 创建测试客户端，handler 通常为 *web.Engine
+func New(t testing.TB, handler http.Handler) *Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse(defaultBaseURL)
+	return &Client{t: t, handler: handler, base: base, jar: jar, header: make(http.Header)}
+}
+
+// WithBaseURL 设置请求的地址，影响 Host 和 Cookie 的作用域，默认为 http://example.com
+func (c *Client) WithBaseURL(rawURL string) *Client {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.base = base
+	return c
+}
+
+// WithHeader 设置每个请求都会带上的请求头，例如 Authorization
+func (c *Client) WithHeader(key string, val string) *Client {
+	c.header.Set(key, val)
+	return c
+}
+
+// Jar 返回保存 Cookie 的 CookieJar
+func (c *Client) Jar() http.CookieJar {
+	return c.jar
+}
+
+// Cookie 返回 Cookie jar 中当前地址可见的 Cookie
+func (c *Client) Cookie(name string) (*http.Cookie, bool) {
+	for _, cookie := range c.jar.Cookies(c.base) {
+		if cookie.Name == name {
+			return cookie, true
+		}
+	}
+	return nil, false
+}
+
+// ClearCookies 清空保存的 Cookie
+func (c *Client) ClearCookies() {
+	jar, _ := cookiejar.New(nil)
+	c.jar = jar
+}
+
+// Get 创建 GET 请求
+func (c *Client) Get(path string) *Request {
+	return c.Request(http.MethodGet, path)
+}
+
+// Post 创建 POST 请求
+func (c *Client) Post(path string) *Request {
+	return c.Request(http.MethodPost, path)
+}
+
+// Put 创建 PUT 请求
+func (c *Client) Put(path string) *Request {
+	return c.Request(http.MethodPut, path)
+}
+
+// Patch 创建 PATCH 请求
+func (c *Client) Patch(path string) *Request {
+	return c.Request(http.MethodPatch, path)
+}
+
+// Delete 创建 DELETE 请求
+func (c *Client) Delete(path string) *Request {
+	return c.Request(http.MethodDelete, path)
+}
+
+// Request 创建指定方法的请求
+func (c *Client) Request(method string, path string) *Request {
+	return &Request{
+		client: c,
+		method: method,
+		path:   path,
+		header: c.header.Clone(),
+		query:  make(url.Values),
+	}
+}
+
+// Request 待发送的请求，通过 With 系列方法设置后调用 Do 发送
+type Request struct {
+	client     *Client
+	method     string
+	path       string
+	header     http.Header
+	query      url.Values
+	body       []byte
+	cookies    []*http.Cookie
+	remoteAddr string
+	err        error
+}
+
+// WithHeader 设置请求头
+func (r *Request) WithHeader(key string, val string) *Request {
+	r.header.Set(key, val)
+	return r
+}
+
+// WithQuery 添加查询参数
+func (r *Request) WithQuery(key string, val string) *Request {
+	r.query.Add(key, val)
+	return r
+}
+
+// WithCookie 添加只用于本次请求的 Cookie
+func (r *Request) WithCookie(name string, val string) *Request {
+	r.cookies = append(r.cookies, &http.Cookie{Name: name, Value: val})
+	return r
+}
+
+// WithBody 设置请求体和内容类型
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// WithJSON 将 val 编码为 JSON 作为请求体
+func (r *Request) WithJSON(val any) *Request {
+	data, err := json.Marshal(val)
+	if err != nil {
+		r.err = err
+	}
+	return r.WithBody("application/json", data)
+}
+
+// WithForm 设置表单请求体
+func (r *Request) WithForm(form url.Values) *Request {
+	return r.WithBody("application/x-www-form-urlencoded", []byte(form.Encode()))
+}
+
+// WithRemoteAddr 设置客户端地址，默认为 192.0.2.1:1234
+func (r *Request) WithRemoteAddr(addr string) *Request {
+	r.remoteAddr = addr
+	return r
+}
+
+// Build 构造 *http.Request，Cookie jar 中的 Cookie 会添加到请求中
+func (r *Request) Build() *http.Request {
+	t := r.client.t
+	t.Helper()
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	target := r.client.base.ResolveReference(&url.URL{Path: r.path})
+	if i := strings.IndexByte(r.path, '?'); i >= 0 {
+		target = r.client.base.ResolveReference(&url.URL{Path: r.path[:i], RawQuery: r.path[i+1:]})
+	}
+	if len(r.query) > 0 {
+		q := target.Query()
+		for k, vals := range r.query {
+			q[k] = append(q[k], vals...)
+		}
+		target.RawQuery = q.Encode()
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req := httptest.NewRequest(r.method, target.String(), body)
+	for k, vals := range r.header {
+		req.Header[k] = vals
+	}
+	for _, cookie := range r.client.jar.Cookies(target) {
+		req.AddCookie(cookie)
+	}
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+	if r.remoteAddr != "" {
+		req.RemoteAddr = r.remoteAddr
+	}
+	return req
+}
+
+// Do 发送请求并返回响应，响应中的 Set-Cookie 会保存到 Cookie jar 中
+func (r *Request) Do() *Response {
+	t := r.client.t
+	t.Helper()
+	req := r.Build()
+	recorder := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	r.client.jar.SetCookies(req.URL, resp.Cookies())
+	return newResponse(t, resp)
+}
@@ -0,0 +1,129 @@
+package webtest
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Andras5014/go-web"
+	"github.com/Andras5014/go-web/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	e := web.NewEngine()
+	e.POST("/user/:id", func(ctx *web.Context) {
+		var req struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		ctx.Header("X-Request-Id", ctx.GetHeader("X-Request-Id"))
+		_ = ctx.JSON(http.StatusCreated, map[string]any{
+			"id":    ctx.PathParams["id"],
+			"page":  ctx.QueryDefault("page", "1"),
+			"token": must(ctx.Req.Cookie("token")).Value,
+			"data":  req,
+		})
+	})
+	e.POST("/form", func(ctx *web.Context) {
+		val, _ := ctx.FormValue("name")
+		_ = ctx.String(http.StatusOK, val)
+	})
+
+	c := New(t, e)
+	c.Post("/user/42?page=2").
+		WithJSON(map[string]any{"name": "tom", "tags": []string{"a", "b"}}).
+		WithCookie("token", "abc").
+		WithHeader("X-Request-Id", "req-1").
+		Do().
+		AssertStatus(http.StatusCreated).
+		AssertHeader("X-Request-Id", "req-1").
+		AssertHeaderContains("Content-Type", "json").
+		AssertJSON("id", "42").
+		AssertJSON("page", "2").
+		AssertJSON("token", "abc").
+		AssertJSON("data.name", "tom").
+		AssertJSON("data.tags.1", "b").
+		AssertJSON("data", map[string]any{"name": "tom", "tags": []string{"a", "b"}})
+
+	c.Post("/user/1").WithBody("application/json", []byte("{")).WithCookie("token", "abc").Do().
+		AssertStatus(http.StatusBadRequest)
+
+	c.Post("/form").WithForm(url.Values{"name": {"jerry"}}).Do().
+		AssertStatus(http.StatusOK).
+		AssertBody("jerry").
+		AssertBodyContains("err")
+
+	resp := c.Post("/user/7").WithQuery("page", "3").WithCookie("token", "t").WithJSON(nil).Do()
+	val, ok := resp.JSONPath("page")
+	assert.True(t, ok)
+	assert.Equal(t, "3", val)
+	_, ok = resp.JSONPath("data.tags.5")
+	assert.False(t, ok)
+	var body struct {
+		ID string `json:"id"`
+	}
+	resp.DecodeJSON(&body)
+	assert.Equal(t, "7", body.ID)
+}
+
+func TestClient_Session(t *testing.T) {
+	m := &session.Manager{
+		Propagator: session.NewCookiePropagator(),
+		Store:      session.NewMemoStore(time.Minute),
+	}
+	e := web.NewEngine()
+	e.POST("/login/:name", func(ctx *web.Context) {
+		name := ctx.PathParams["name"]
+		sess, err := m.InitSession(ctx, "sess-"+name)
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		_ = sess.Set("name", name)
+		_ = ctx.String(http.StatusOK, "login success")
+	})
+	e.POST("/logout", func(ctx *web.Context) {
+		if err := m.RemoveSession(ctx); err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		_ = ctx.String(http.StatusOK, "logout success")
+	})
+	user := e.Group("/user")
+	user.Use(session.NeedSession(m, nil))
+	user.GET("/hello", func(ctx *web.Context) {
+		sess, _ := m.GetSession(ctx)
+		name, _ := sess.Get("name")
+		_ = ctx.String(http.StatusOK, "hello "+name.(string))
+	})
+
+	c := New(t, e)
+	c.Get("/user/hello").Do().AssertStatus(http.StatusUnauthorized)
+	c.Post("/login/tom").Do().AssertStatus(http.StatusOK)
+	cookie, ok := c.Cookie("session_id")
+	require.True(t, ok)
+	assert.Equal(t, "sess-tom", cookie.Value)
+
+	// Cookie jar 在多次请求之间保存会话
+	c.Get("/user/hello").Do().AssertStatus(http.StatusOK).AssertBody("hello tom")
+	c.Post("/logout").Do().AssertStatus(http.StatusOK)
+	c.Get("/user/hello").Do().AssertStatus(http.StatusUnauthorized)
+
+	c.Post("/login/jerry").Do()
+	c.ClearCookies()
+	c.Get("/user/hello").Do().AssertStatus(http.StatusUnauthorized)
+}
+
+func must[T any](val T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return val
+}
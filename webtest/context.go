@@ -0,0 +1,46 @@
+package webtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andras5014/go-web"
+)
+
+// Context 用于单独测试处理函数和中间件的上下文
+// 可以在 Run 之前设置 PathParams、通过 Set 设置值，Run 之后检查 StatusCode、RespData 等字段
+type Context struct {
+	*web.Context
+	Recorder *httptest.ResponseRecorder
+
+	t      testing.TB
+	engine *web.Engine
+}
+
+// NewContext 创建测试上下文，req 为 nil 时使用 GET / 请求，opts 用于创建所属的引擎
+func NewContext(t testing.TB, req *http.Request, opts ...web.EngineOption) *Context {
+	if req == nil {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+	}
+	engine := web.NewEngine(opts...)
+	recorder := httptest.NewRecorder()
+	return &Context{
+		Context:  engine.NewContext(recorder, req),
+		Recorder: recorder,
+		t:        t,
+		engine:   engine,
+	}
+}
+
+// Engine 返回上下文所属的引擎
+func (c *Context) Engine() *web.Engine {
+	return c.engine
+}
+
+// Run 依次执行 handlers 并发送响应，返回的 Response 用于断言
+func (c *Context) Run(handlers ...web.HandleFunc) *Response {
+	c.t.Helper()
+	c.engine.HandleContext(c.Context, handlers...)
+	return newResponse(c.t, c.Recorder.Result())
+}
@@ -0,0 +1,54 @@
+package webtest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Andras5014/go-web"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	auth := func(ctx *web.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.AbortWithError(web.NewProblem(http.StatusUnauthorized, "missing token"))
+			return
+		}
+		ctx.Set("user", "tom")
+		ctx.Next()
+	}
+	handler := func(ctx *web.Context) {
+		user, _ := web.GetAs[string](ctx, "user")
+		_ = ctx.String(http.StatusOK, user+" "+ctx.PathParams["id"])
+	}
+
+	// 中间件中止请求
+	c := NewContext(t, nil, web.WithProblemDetails())
+	c.Run(auth, handler).
+		AssertStatus(http.StatusUnauthorized).
+		AssertHeader("Content-Type", web.ProblemContentType).
+		AssertJSON("detail", "missing token")
+	assert.True(t, c.IsAborted())
+
+	req := httptest.NewRequest(http.MethodGet, "/user/1", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	c = NewContext(t, req)
+	c.PathParams = map[string]string{"id": "1"}
+	c.Run(auth, handler).AssertStatus(http.StatusOK).AssertBody("tom 1")
+	assert.Equal(t, http.StatusOK, c.StatusCode)
+
+	// 单独测试中间件，没有设置状态码时为 200
+	c = NewContext(t, req)
+	c.Run(auth).AssertStatus(http.StatusOK).AssertBody("")
+	user, err := web.GetAs[string](c.Context, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "tom", user)
+
+	c = NewContext(t, nil)
+	c.Run(func(ctx *web.Context) {
+		ctx.AbortWithError(errors.New("db down"))
+	}).AssertStatus(http.StatusInternalServerError)
+	assert.NotNil(t, c.Engine())
+}
@@ -0,0 +1,138 @@
+package webtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Response 请求的响应，Assert 系列方法断言失败时标记测试失败并继续执行，可以链式调用
+type Response struct {
+	*http.Response
+	t    testing.TB
+	Body []byte // 响应体
+}
+
+// newResponse 读取响应体并创建 Response
+func newResponse(t testing.TB, resp *http.Response) *Response {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	return &Response{Response: resp, t: t, Body: body}
+}
+
+// String 返回响应体
+func (r *Response) String() string {
+	return string(r.Body)
+}
+
+// Cookie 返回响应中设置的 Cookie
+func (r *Response) Cookie(name string) (*http.Cookie, bool) {
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == name {
+			return cookie, true
+		}
+	}
+	return nil, false
+}
+
+// DecodeJSON 将响应体解析到 val 中，失败时终止测试
+func (r *Response) DecodeJSON(val any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, val); err != nil {
+		r.t.Fatalf("decode JSON response %q: %v", r.Body, err)
+	}
+	return r
+}
+
+// AssertStatus 断言状态码
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, code, r.StatusCode, "status code, body: %s", r.Body)
+	return r
+}
+
+// AssertHeader 断言响应头
+func (r *Response) AssertHeader(key string, val string) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, val, r.Header.Get(key), "header %s", key)
+	return r
+}
+
+// AssertHeaderContains 断言响应头包含 substr
+func (r *Response) AssertHeaderContains(key string, substr string) *Response {
+	r.t.Helper()
+	assert.Contains(r.t, r.Header.Get(key), substr, "header %s", key)
+	return r
+}
+
+// AssertBody 断言响应体
+func (r *Response) AssertBody(body string) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, body, string(r.Body))
+	return r
+}
+
+// AssertBodyContains 断言响应体包含 substr
+func (r *Response) AssertBodyContains(substr string) *Response {
+	r.t.Helper()
+	assert.Contains(r.t, string(r.Body), substr)
+	return r
+}
+
+// AssertJSON 断言响应体中 path 位置的 JSON 值等于 want
+// path 由 . 分隔，数组使用下标，例如 data.items.0.name；path 为空时比较整个响应体
+// want 会先编码为 JSON 再解析，因此可以直接使用 int、结构体等类型
+func (r *Response) AssertJSON(path string, want any) *Response {
+	r.t.Helper()
+	got, ok := r.JSONPath(path)
+	if !ok {
+		assert.Fail(r.t, "JSON path not found", "path %q in %s", path, r.Body)
+		return r
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	var normalized any
+	_ = json.Unmarshal(data, &normalized)
+	assert.Equal(r.t, normalized, got, "JSON path %q", path)
+	return r
+}
+
+// JSONPath 返回响应体中 path 位置的 JSON 值，格式同 AssertJSON
+func (r *Response) JSONPath(path string) (any, bool) {
+	var val any
+	if err := json.Unmarshal(r.Body, &val); err != nil {
+		return nil, false
+	}
+	if path == "" {
+		return val, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch cur := val.(type) {
+		case map[string]any:
+			var ok bool
+			if val, ok = cur[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(cur) {
+				return nil, false
+			}
+			val = cur[i]
+		default:
+			return nil, false
+		}
+	}
+	return val, true
+}